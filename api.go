@@ -6,21 +6,16 @@ import (
 	"strings"
 )
 
-// InkbunnyUrl is a helper function to generate Inkbunny URLs with a given path and optional query parameters
-func InkbunnyUrl(path string, values ...url.Values) *url.URL {
-	request := &url.URL{
-		Scheme: "https",
-		Host:   "inkbunny.net",
-		Path:   path,
-	}
+// DefaultBaseURL is the production Inkbunny host used when no Client base URL is set.
+const DefaultBaseURL = "https://inkbunny.net"
 
-	var valueStrings []string
-	for _, value := range values {
-		valueStrings = append(valueStrings, value.Encode())
-	}
-	request.RawQuery = strings.Join(valueStrings, "&")
+var defaultBaseURL, _ = url.Parse(DefaultBaseURL)
 
-	return request
+// InkbunnyUrl is a helper function to generate Inkbunny URLs with a given path and optional query parameters.
+// The path is escaped, e.g. InkbunnyUrl("some one") is "https://inkbunny.net/some%20one".
+// It always points to DefaultBaseURL, use Client.InkbunnyUrl to respect WithBaseURL.
+func InkbunnyUrl(path string, values ...url.Values) *url.URL {
+	return joinUrl(defaultBaseURL, path, values...)
 }
 
 // ApiUrl is a helper function to generate Inkbunny API URLs.
@@ -29,5 +24,53 @@ func InkbunnyUrl(path string, values ...url.Values) *url.URL {
 //
 //	url := ApiUrl("login", url.Values{"username": {"guest"}, "password": {""}})
 func ApiUrl(path string, values ...url.Values) *url.URL {
-	return InkbunnyUrl(fmt.Sprintf("api_%v.php", path), values...)
+	return InkbunnyUrl(apiPath(path), values...)
+}
+
+// InkbunnyUrl is like the package-level InkbunnyUrl but uses the Client's base URL,
+// set by WithBaseURL or Client.SetBaseURL.
+func (c *Client) InkbunnyUrl(path string, values ...url.Values) *url.URL {
+	return joinUrl(c.Get().BaseURL(), path, values...)
+}
+
+// ApiUrl is like the package-level ApiUrl but uses the Client's base URL, set by WithBaseURL or Client.SetBaseURL.
+//
+//	client := NewClient(WithBaseURL(&url.URL{Scheme: "http", Host: "127.0.0.1:8080"}))
+//	url := client.ApiUrl("login") // http://127.0.0.1:8080/api_login.php
+func (c *Client) ApiUrl(path string, values ...url.Values) *url.URL {
+	return c.InkbunnyUrl(apiPath(path), values...)
+}
+
+func apiPath(path string) string {
+	return fmt.Sprintf("api_%v.php", path)
+}
+
+// joinUrl appends path to the path of base. The path is escaped when the URL is formatted, so it must not be escaped.
+func joinUrl(base *url.URL, path string, values ...url.Values) *url.URL {
+	request := *base
+	request.Path = strings.TrimSuffix(base.Path, "/") + "/" + strings.TrimPrefix(path, "/")
+	request.RawPath = ""
+	request.RawQuery = ""
+	request.Fragment = ""
+
+	var valueStrings []string
+	for _, value := range values {
+		valueStrings = append(valueStrings, value.Encode())
+	}
+	request.RawQuery = strings.Join(valueStrings, "&")
+
+	return &request
+}
+
+// rebase rewrites a URL pointing to DefaultBaseURL so that it points to the Client base URL instead.
+// URLs to any other host are returned as is.
+func (c *Client) rebase(u *url.URL) *url.URL {
+	base := c.BaseURL()
+	if base == defaultBaseURL || u.Scheme != defaultBaseURL.Scheme || u.Host != defaultBaseURL.Host {
+		return u
+	}
+	rebased := joinUrl(base, u.Path)
+	rebased.RawQuery = u.RawQuery
+	rebased.Fragment = u.Fragment
+	return rebased
 }
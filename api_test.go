@@ -0,0 +1,50 @@
+package inkbunny_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/ellypaws/inkbunny"
+)
+
+func TestBaseURLWithPath(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.EscapedPath())
+	}))
+	defer server.Close()
+	base, err := url.Parse(server.URL + "/my%20proxy/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := inkbunny.NewClient(inkbunny.WithBaseURL(base))
+
+	for _, test := range []struct {
+		url  *url.URL
+		want string
+	}{
+		{client.ApiUrl("login"), server.URL + "/my%20proxy/api_login.php"},
+		{client.InkbunnyUrl("some one"), server.URL + "/my%20proxy/some%20one"},
+		{client.InkbunnyUrl("a%b"), server.URL + "/my%20proxy/a%25b"},
+		{client.InkbunnyUrl("/files/a/b", url.Values{"c": {"d"}}), server.URL + "/my%20proxy/files/a/b?c=d"},
+	} {
+		if test.url.String() != test.want {
+			t.Errorf("got %s, want %s", test.url, test.want)
+		}
+	}
+
+	// URLs pointing to DefaultBaseURL are sent to the base URL, keeping its path prefix.
+	for _, path := range []string{"some one", "files/a%b"} {
+		response, err := client.PostForm(inkbunny.InkbunnyUrl(path), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if want := []string{"/my%20proxy/some%20one", "/my%20proxy/files/a%25b"}; !slices.Equal(got, want) {
+		t.Errorf("got paths %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,260 @@
+package inkbunny
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// bbTag matches an opening or closing BBCode tag at the start of a string, e.g. "[b]", "[/b]" or "[url=...]".
+var bbTag = regexp.MustCompile(`^\[(/?)([a-zA-Z]+)(?:=([^\]]*))?\]`)
+
+// bbTags are the BBCode tags supported by Inkbunny. Other tags are kept as text.
+var bbTags = map[string]bool{
+	"b": true, "i": true, "u": true, "s": true, "t": true,
+	"q": true, "quote": true, "center": true, "left": true, "right": true,
+	"color": true, "url": true,
+	"icon": true, "iconname": true, "name": true,
+	"fa": true, "sf": true, "da": true, "w": true,
+}
+
+// bbProfiles are the addresses of the profiles linked by the BBCode tags of other websites.
+var bbProfiles = map[string]string{
+	"fa": "https://www.furaffinity.net/user/%s/",
+	"sf": "https://%s.sofurry.com/",
+	"da": "https://www.deviantart.com/%s",
+	"w":  "https://www.weasyl.com/~%s",
+}
+
+var bbColor = regexp.MustCompile(`^#?[0-9a-zA-Z]+$`)
+
+// bbNode is a tag or, when tag is empty, the text of parsed BBCode.
+type bbNode struct {
+	tag      string
+	arg      string
+	text     string
+	children []*bbNode
+}
+
+// parseBBCode parses BBCode into a tree. Unknown tags and closing tags without an opening tag are kept as text,
+// and tags that are not closed end with their parent.
+func parseBBCode(s string) *bbNode {
+	root := &bbNode{}
+	stack := []*bbNode{root}
+	text := func(s string) {
+		if s == "" {
+			return
+		}
+		top := stack[len(stack)-1]
+		if n := len(top.children); n > 0 && top.children[n-1].tag == "" {
+			top.children[n-1].text += s
+			return
+		}
+		top.children = append(top.children, &bbNode{text: s})
+	}
+	for s != "" {
+		i := strings.IndexByte(s, '[')
+		if i < 0 {
+			text(s)
+			break
+		}
+		text(s[:i])
+		s = s[i:]
+		m := bbTag.FindStringSubmatch(s)
+		if m == nil || !bbTags[strings.ToLower(m[2])] {
+			text(s[:1])
+			s = s[1:]
+			continue
+		}
+		s = s[len(m[0]):]
+		name := strings.ToLower(m[2])
+		if m[1] == "" {
+			node := &bbNode{tag: name, arg: strings.Trim(m[3], `"'`)}
+			top := stack[len(stack)-1]
+			top.children = append(top.children, node)
+			stack = append(stack, node)
+			continue
+		}
+		open := -1
+		for j := len(stack) - 1; j > 0; j-- {
+			if stack[j].tag == name || name == "quote" && stack[j].tag == "q" || name == "q" && stack[j].tag == "quote" {
+				open = j
+				break
+			}
+		}
+		if open < 0 {
+			text(m[0])
+			continue
+		}
+		stack = stack[:open]
+	}
+	return root
+}
+
+// plain returns the text of a node without its tags.
+func (n *bbNode) plain() string {
+	var b strings.Builder
+	b.WriteString(n.text)
+	for _, child := range n.children {
+		b.WriteString(child.plain())
+	}
+	return b.String()
+}
+
+// link returns the address of a url tag or of a profile tag, or an empty string if it is not a safe link.
+func (n *bbNode) link() string {
+	name := strings.TrimSpace(n.plain())
+	var link string
+	switch n.tag {
+	case "url":
+		link = strings.TrimSpace(n.arg)
+		if link == "" {
+			link = name
+		}
+	case "icon", "iconname", "name":
+		link = InkbunnyUrl(name).String()
+	case "fa", "sf", "da", "w":
+		link = fmt.Sprintf(bbProfiles[n.tag], url.PathEscape(name))
+	default:
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return link
+	}
+	return ""
+}
+
+// BBCodeToHTML converts Inkbunny BBCode, such as SubmissionDetails.Writing or Description, to XHTML.
+// Text is escaped, line breaks become <br/>, and only http, https and mailto links are kept.
+//
+//	html := inkbunny.BBCodeToHTML("[b]Chapter 1[/b]\n[url=https://inkbunny.net]Inkbunny[/url]")
+func BBCodeToHTML(s string) string {
+	var b strings.Builder
+	parseBBCode(normalizeNewlines(s)).html(&b)
+	return b.String()
+}
+
+func (n *bbNode) html(b *strings.Builder) {
+	open, end := "", ""
+	switch n.tag {
+	case "":
+		b.WriteString(strings.ReplaceAll(html.EscapeString(n.text), "\n", "<br/>\n"))
+	case "b":
+		open, end = "<strong>", "</strong>"
+	case "i":
+		open, end = "<em>", "</em>"
+	case "u":
+		open, end = `<span style="text-decoration: underline">`, "</span>"
+	case "s":
+		open, end = "<del>", "</del>"
+	case "t":
+		open, end = "<h3>", "</h3>"
+	case "q", "quote":
+		open, end = "<blockquote>", "</blockquote>"
+		if n.arg != "" {
+			open += "<p><strong>" + html.EscapeString(n.arg) + "</strong> wrote:</p>"
+		}
+	case "center", "left", "right":
+		open, end = `<div style="text-align: `+n.tag+`">`, "</div>"
+	case "color":
+		if bbColor.MatchString(n.arg) {
+			open, end = `<span style="color: `+n.arg+`">`, "</span>"
+		}
+	default:
+		if link := n.link(); link != "" {
+			open, end = `<a href="`+html.EscapeString(link)+`">`, "</a>"
+		}
+	}
+	b.WriteString(open)
+	for _, child := range n.children {
+		child.html(b)
+	}
+	b.WriteString(end)
+}
+
+// markdownEscaper escapes the characters that have a meaning in Markdown.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "#", `\#`, "~", `\~`,
+)
+
+// BBCodeToMarkdown converts Inkbunny BBCode, such as SubmissionDetails.Writing or Description, to CommonMark.
+// Single line breaks become hard line breaks, and tags without an equivalent, such as [u] or [color], keep only
+// their text.
+func BBCodeToMarkdown(s string) string {
+	var b strings.Builder
+	parseBBCode(normalizeNewlines(s)).markdown(&b)
+	return strings.TrimSpace(collapseNewlines(b.String()))
+}
+
+func (n *bbNode) markdown(b *strings.Builder) {
+	inner := func() string {
+		var b strings.Builder
+		for _, child := range n.children {
+			child.markdown(&b)
+		}
+		return b.String()
+	}
+	switch n.tag {
+	case "":
+		lines := strings.Split(markdownEscaper.Replace(n.text), "\n")
+		for i, line := range lines {
+			if i > 0 {
+				if out := b.String(); out != "" && !strings.HasSuffix(out, "\n") {
+					b.WriteString("  ")
+				}
+				b.WriteString("\n")
+			}
+			b.WriteString(line)
+		}
+		b.WriteString(inner())
+	case "b":
+		b.WriteString(wrap("**", inner()))
+	case "i":
+		b.WriteString(wrap("*", inner()))
+	case "s":
+		b.WriteString(wrap("~~", inner()))
+	case "t":
+		b.WriteString("\n\n### " + strings.ReplaceAll(strings.TrimSpace(inner()), "\n", " ") + "\n\n")
+	case "q", "quote":
+		text := strings.TrimSpace(inner())
+		if n.arg != "" {
+			text = "**" + markdownEscaper.Replace(n.arg) + "** wrote:\n\n" + text
+		}
+		b.WriteString("\n\n> " + strings.ReplaceAll(collapseNewlines(text), "\n", "\n> ") + "\n\n")
+	default:
+		text := inner()
+		if link := n.link(); link != "" {
+			text = "[" + text + "](<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(link) + ">)"
+		}
+		b.WriteString(text)
+	}
+}
+
+// wrap surrounds text with a Markdown delimiter, keeping surrounding spaces outside so that it stays valid.
+func wrap(delimiter, text string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := text[:strings.Index(text, trimmed)]
+	end := text[len(start)+len(trimmed):]
+	return start + delimiter + trimmed + delimiter + end
+}
+
+func normalizeNewlines(s string) string {
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+}
+
+var blankLines = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+
+// collapseNewlines replaces three or more line breaks with a single blank line.
+func collapseNewlines(s string) string {
+	return blankLines.ReplaceAllString(s, "\n\n")
+}
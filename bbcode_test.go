@@ -0,0 +1,41 @@
+package inkbunny_test
+
+import (
+	"testing"
+
+	"github.com/ellypaws/inkbunny"
+)
+
+func TestBBCodeToHTML(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"[b]bold[/b] & <text>", "<strong>bold</strong> &amp; &lt;text&gt;"},
+		{"line\nbreak", "line<br/>\nbreak"},
+		{"[icon]artist[/icon]", `<a href="https://inkbunny.net/artist">artist</a>`},
+		{"[name]some one[/name]", `<a href="https://inkbunny.net/some%20one">some one</a>`},
+		{"[iconname]a%b[/iconname]", `<a href="https://inkbunny.net/a%25b">a%b</a>`},
+		{"[fa]some one[/fa]", `<a href="https://www.furaffinity.net/user/some%20one/">some one</a>`},
+		{"[url=javascript:alert(1)]link[/url]", "link"},
+		{"[url]https://inkbunny.net[/url]", `<a href="https://inkbunny.net">https://inkbunny.net</a>`},
+	} {
+		if got := inkbunny.BBCodeToHTML(test.in); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestBBCodeToMarkdown(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"[b]bold[/b] *text*", `**bold** \*text\*`},
+		{"line\nbreak", "line  \nbreak"},
+		{"[name]some one[/name]", "[some one](<https://inkbunny.net/some%20one>)"},
+		{"[t]Chapter 1[/t]\ntext", "### Chapter 1\n\ntext"},
+	} {
+		if got := inkbunny.BBCodeToMarkdown(test.in); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package inkbunny
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// Book is a set of written submissions, such as the chapters of a serialized story, that can be exported
+// as an EPUB 3 or as Markdown with one chapter per submission. Use NewBook or User.PoolBook to create one.
+//
+// Chapters are read from SubmissionDetails.Writing, which is requested with SubmissionDetailsRequest.ShowWriting,
+// and their BBCode is converted with BBCodeToHTML and BBCodeToMarkdown.
+type Book struct {
+	ID          string // ID is the unique identifier of the book, e.g. "urn:inkbunny:pool:1234".
+	Title       string
+	Author      string
+	Description string // Description is in BBCode, like SubmissionDetails.Description.
+	Language    string // Language is the BCP 47 language of the book. Defaults to "en".
+	Chapters    []SubmissionDetails
+	CoverURL    string // CoverURL is the thumbnail of the first chapter, linked from the Markdown.
+	Cover       []byte // Cover is the image embedded in the EPUB, see Book.FetchCover.
+}
+
+// NewBook returns a Book with one chapter per written submission, in order. Submissions that are not of type
+// SubmissionTypeWritingDocument and have no Writing, such as the pictures of a pool, are left out.
+// The title, author and description are those of the first chapter, or of the pool shared by every
+// chapter when there is more than one.
+func NewBook(submissions ...SubmissionDetails) *Book {
+	book := &Book{Language: "en"}
+	for _, submission := range submissions {
+		if SubmissionType(submission.SubmissionTypeID) == SubmissionTypeWritingDocument || submission.Writing != "" {
+			book.Chapters = append(book.Chapters, submission)
+		}
+	}
+	if len(book.Chapters) == 0 {
+		return book
+	}
+	first := book.Chapters[0]
+	book.ID = "urn:inkbunny:submission:" + first.SubmissionID.String()
+	book.Title = first.Title
+	book.Description = first.Description
+	book.CoverURL = cmp.Or(
+		first.ThumbnailURLHuge, first.ThumbnailURLLarge, first.ThumbnailURLMedium,
+		first.ThumbnailURLHugeNonCustom, first.ThumbnailURLLargeNonCustom, first.ThumbnailURLMediumNonCustom,
+	)
+	if pool, ok := commonPool(book.Chapters); ok && len(book.Chapters) > 1 {
+		book.usePool(pool)
+	}
+	var authors []string
+	for _, chapter := range book.Chapters {
+		authors = appendUnique(authors, chapter.Username)
+	}
+	book.Author = strings.Join(authors, ", ")
+	return book
+}
+
+func (b *Book) usePool(pool Pool) {
+	b.ID = "urn:inkbunny:pool:" + pool.PoolID.String()
+	b.Title = pool.Name
+	b.Description = cmp.Or(pool.Description, b.Description)
+}
+
+// PoolBook returns a Book of every written submission of a pool, in pool order, with its thumbnail as the cover.
+// It returns ErrNoFiles if the pool has no written submission.
+//
+//	book, err := user.PoolBook(poolID)
+//	if err != nil {
+//		return err
+//	}
+//	err = book.WriteEPUB(f)
+func (u *User) PoolBook(poolID types.IntString) (*Book, error) {
+	return u.PoolBookContext(u.Client().Context(), poolID)
+}
+
+// PoolBookContext is like User.PoolBook but uses ctx for the requests.
+func (u *User) PoolBookContext(ctx context.Context, poolID types.IntString) (*Book, error) {
+	submissions, err := u.PoolSubmissionsContext(ctx, poolID)
+	if err != nil {
+		return nil, err
+	}
+	book := NewBook(submissions...)
+	if len(book.Chapters) == 0 {
+		return nil, ErrNoFiles
+	}
+	for _, pool := range submissions[0].Pools {
+		if pool.PoolID == poolID {
+			book.usePool(pool)
+		}
+	}
+	return book, book.FetchCover(ctx, u.Client())
+}
+
+// FetchCover downloads the image at CoverURL into Cover, using the http.Client and rate limiters of the Client.
+// It does nothing if CoverURL is empty.
+func (b *Book) FetchCover(ctx context.Context, client *Client) error {
+	if b.CoverURL == "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.CoverURL, nil)
+	if err != nil {
+		return err
+	}
+	response, err := client.Get().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return &types.APIError{
+			Code:       types.ErrUnexpectedStatus,
+			Message:    response.Status,
+			Endpoint:   req.URL.Path,
+			StatusCode: response.StatusCode,
+		}
+	}
+	b.Cover, err = io.ReadAll(response.Body)
+	return err
+}
+
+// coverTypes are the extensions of the image types that EPUB readers support.
+var coverTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// modified returns the latest date of the chapters, or the current time if they have none.
+func (b *Book) modified() time.Time {
+	var modified time.Time
+	for _, chapter := range b.Chapters {
+		for _, date := range []types.Time{chapter.CreateDateSystem, chapter.UpdateDateSystem} {
+			if date.After(modified) {
+				modified = date.Time
+			}
+		}
+	}
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	return modified.UTC().Truncate(time.Second)
+}
+
+// WriteEPUB writes the Book as an EPUB 3, with a table of contents and the Cover if it is a JPEG, PNG, GIF or WebP image.
+// Each chapter contains the title, the writing and the description of a submission.
+func (b *Book) WriteEPUB(w io.Writer) error {
+	if len(b.Chapters) == 0 {
+		return ErrNoFiles
+	}
+	archive := zip.NewWriter(w)
+	// The mimetype must be the first file of the archive, and must not be compressed.
+	mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"META-INF/container.xml": epubContainer,
+		"OEBPS/style.css":        epubStyle,
+		"OEBPS/nav.xhtml":        b.nav(),
+	}
+	coverType := http.DetectContentType(b.Cover)
+	cover := ""
+	if ext, ok := coverTypes[coverType]; ok && len(b.Cover) > 0 {
+		cover = "cover" + ext
+		files["OEBPS/cover.xhtml"] = xhtml(b.Language, "Cover",
+			`<section epub:type="cover"><img class="cover" src="`+cover+`" alt="`+html.EscapeString(b.Title)+`"/></section>`)
+	}
+	for i, chapter := range b.Chapters {
+		files["OEBPS/"+chapterName(i)] = b.chapter(chapter)
+	}
+	files["OEBPS/content.opf"] = b.opf(cover, coverType)
+
+	names := []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/style.css"}
+	if cover != "" {
+		names = append(names, "OEBPS/cover.xhtml")
+	}
+	for i := range b.Chapters {
+		names = append(names, "OEBPS/"+chapterName(i))
+	}
+	for _, name := range names {
+		out, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(out, files[name]); err != nil {
+			return err
+		}
+	}
+	if cover != "" {
+		out, err := archive.CreateHeader(&zip.FileHeader{Name: "OEBPS/" + cover, Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(b.Cover); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const epubStyle = `body { font-family: serif; line-height: 1.5; }
+h1 { text-align: center; }
+p.byline { text-align: center; font-style: italic; }
+img.cover { display: block; max-width: 100%; max-height: 100%; margin: 0 auto; }
+blockquote { margin: 1em 2em; font-style: italic; }
+div.description { font-size: 0.9em; }
+`
+
+func chapterName(i int) string {
+	return fmt.Sprintf("chapter-%03d.xhtml", i+1)
+}
+
+func xhtml(language, title, body string) string {
+	language = html.EscapeString(cmp.Or(language, "en"))
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="` + language + `" xml:lang="` + language + `">
+<head>
+  <meta charset="UTF-8"/>
+  <title>` + html.EscapeString(title) + `</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+` + body + `
+</body>
+</html>
+`
+}
+
+func (b *Book) chapter(chapter SubmissionDetails) string {
+	var body strings.Builder
+	body.WriteString(`<section epub:type="chapter">` + "\n")
+	body.WriteString("<h1>" + html.EscapeString(chapter.Title) + "</h1>\n")
+	if chapter.Username != "" && chapter.Username != b.Author {
+		body.WriteString(`<p class="byline">by ` + html.EscapeString(chapter.Username) + "</p>\n")
+	}
+	if chapter.Writing != "" {
+		body.WriteString("<div>" + BBCodeToHTML(chapter.Writing) + "</div>\n")
+	}
+	if chapter.Description != "" {
+		if chapter.Writing != "" {
+			body.WriteString("<hr/>\n")
+		}
+		body.WriteString(`<div class="description">` + BBCodeToHTML(chapter.Description) + "</div>\n")
+	}
+	body.WriteString("</section>")
+	return xhtml(b.Language, chapter.Title, body.String())
+}
+
+func (b *Book) nav() string {
+	var body strings.Builder
+	body.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>Contents</h1>\n<ol>\n")
+	for i, chapter := range b.Chapters {
+		body.WriteString(`  <li><a href="` + chapterName(i) + `">` + html.EscapeString(chapter.Title) + "</a></li>\n")
+	}
+	body.WriteString("</ol>\n</nav>")
+	return xhtml(b.Language, b.Title, body.String())
+}
+
+func (b *Book) opf(cover, coverType string) string {
+	var s strings.Builder
+	s.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" xml:lang="` + html.EscapeString(cmp.Or(b.Language, "en")) + `">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	s.WriteString(`    <dc:identifier id="id">` + html.EscapeString(cmp.Or(b.ID, "urn:inkbunny:book")) + "</dc:identifier>\n")
+	s.WriteString("    <dc:title>" + html.EscapeString(b.Title) + "</dc:title>\n")
+	s.WriteString("    <dc:language>" + html.EscapeString(cmp.Or(b.Language, "en")) + "</dc:language>\n")
+	if b.Author != "" {
+		s.WriteString("    <dc:creator>" + html.EscapeString(b.Author) + "</dc:creator>\n")
+	}
+	if b.Description != "" {
+		s.WriteString("    <dc:description>" + html.EscapeString(parseBBCode(b.Description).plain()) + "</dc:description>\n")
+	}
+	s.WriteString("    <dc:publisher>Inkbunny</dc:publisher>\n")
+	s.WriteString("    <dc:source>" + html.EscapeString(b.Chapters[0].URL().String()) + "</dc:source>\n")
+	s.WriteString(`    <meta property="dcterms:modified">` + b.modified().Format(time.RFC3339) + "</meta>\n")
+	if cover != "" {
+		// The cover meta is read by EPUB 2 readers.
+		s.WriteString(`    <meta name="cover" content="cover-image"/>` + "\n")
+	}
+	s.WriteString("  </metadata>\n  <manifest>\n")
+	s.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	s.WriteString(`    <item id="style" href="style.css" media-type="text/css"/>` + "\n")
+	if cover != "" {
+		s.WriteString(`    <item id="cover-image" href="` + cover + `" media-type="` + coverType + `" properties="cover-image"/>` + "\n")
+		s.WriteString(`    <item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>` + "\n")
+	}
+	for i := range b.Chapters {
+		s.WriteString(fmt.Sprintf(`    <item id="chapter-%d" href="%s" media-type="application/xhtml+xml"/>`+"\n", i+1, chapterName(i)))
+	}
+	s.WriteString("  </manifest>\n  <spine>\n")
+	if cover != "" {
+		s.WriteString(`    <itemref idref="cover" linear="no"/>` + "\n")
+	}
+	s.WriteString(`    <itemref idref="nav"/>` + "\n")
+	for i := range b.Chapters {
+		s.WriteString(fmt.Sprintf(`    <itemref idref="chapter-%d"/>`+"\n", i+1))
+	}
+	s.WriteString("  </spine>\n</package>\n")
+	return s.String()
+}
+
+// WriteMarkdown writes the Book as a single Markdown document, with a heading per chapter.
+// The cover is linked from CoverURL rather than embedded.
+func (b *Book) WriteMarkdown(w io.Writer) error {
+	if len(b.Chapters) == 0 {
+		return ErrNoFiles
+	}
+	var s strings.Builder
+	s.WriteString("# " + markdownEscaper.Replace(b.Title) + "\n\n")
+	if b.Author != "" {
+		s.WriteString("*by " + markdownEscaper.Replace(b.Author) + "*\n\n")
+	}
+	if b.CoverURL != "" {
+		s.WriteString("![Cover](<" + b.CoverURL + ">)\n\n")
+	}
+	if b.Description != "" {
+		s.WriteString(BBCodeToMarkdown(b.Description) + "\n\n")
+	}
+	for _, chapter := range b.Chapters {
+		s.WriteString("## " + markdownEscaper.Replace(chapter.Title) + "\n\n")
+		if chapter.Username != "" && chapter.Username != b.Author {
+			s.WriteString("*by " + markdownEscaper.Replace(chapter.Username) + "*\n\n")
+		}
+		if chapter.Writing != "" {
+			s.WriteString(BBCodeToMarkdown(chapter.Writing) + "\n\n")
+		}
+		if chapter.Description != "" && (len(b.Chapters) > 1 || chapter.Description != b.Description) {
+			if chapter.Writing != "" {
+				s.WriteString("---\n\n")
+			}
+			s.WriteString(BBCodeToMarkdown(chapter.Description) + "\n\n")
+		}
+	}
+	_, err := io.WriteString(w, strings.TrimRight(s.String(), "\n")+"\n")
+	return err
+}
@@ -0,0 +1,199 @@
+package inkbunny_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func story() []inkbunny.SubmissionDetails {
+	pools := []inkbunny.Pool{{PoolID: 7, Name: "The Story", Description: "A [b]long[/b] story"}}
+	return []inkbunny.SubmissionDetails{
+		{
+			SubmissionBasic: inkbunny.SubmissionBasic{
+				SubmissionID: 1, Title: "Chapter 1", Username: "writer",
+				SubmissionTypeID: types.IntString(inkbunny.SubmissionTypeWritingDocument),
+			},
+			Writing: "It was a [i]dark[/i] night & cold.",
+			Pools:   pools,
+		},
+		{
+			SubmissionBasic: inkbunny.SubmissionBasic{
+				SubmissionID: 2, Title: "Cover art", Username: "writer",
+				SubmissionTypeID: types.IntString(inkbunny.SubmissionTypePicturePinup),
+			},
+			Pools: pools,
+		},
+		{
+			SubmissionBasic: inkbunny.SubmissionBasic{
+				SubmissionID: 3, Title: "Chapter 2 <end>", Username: "writer",
+				SubmissionTypeID: types.IntString(inkbunny.SubmissionTypeWritingDocument),
+			},
+			Writing:     "The end.",
+			Description: "Thanks for reading",
+			Pools:       pools,
+		},
+	}
+}
+
+func TestNewBook(t *testing.T) {
+	book := inkbunny.NewBook(story()...)
+	var titles []string
+	for _, chapter := range book.Chapters {
+		titles = append(titles, chapter.Title)
+	}
+	if want := []string{"Chapter 1", "Chapter 2 <end>"}; !slices.Equal(titles, want) {
+		t.Errorf("got chapters %q, want %q", titles, want)
+	}
+	if book.ID != "urn:inkbunny:pool:7" || book.Title != "The Story" || book.Author != "writer" {
+		t.Errorf("got ID %q, title %q and author %q", book.ID, book.Title, book.Author)
+	}
+
+	pictures := inkbunny.NewBook(story()[1])
+	if len(pictures.Chapters) != 0 {
+		t.Errorf("got %d chapters for a picture, want none", len(pictures.Chapters))
+	}
+	if err := pictures.WriteEPUB(io.Discard); !errors.Is(err, inkbunny.ErrNoFiles) {
+		t.Errorf("got %v, want ErrNoFiles", err)
+	}
+}
+
+// opf is the part of an EPUB package document checked by TestWriteEPUB.
+type opf struct {
+	Title    string `xml:"metadata>title"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+func TestWriteEPUB(t *testing.T) {
+	book := inkbunny.NewBook(story()...)
+	book.Cover = []byte("\x89PNG\r\n\x1a\n")
+	var b bytes.Buffer
+	if err := book.WriteEPUB(&b); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mimetype := archive.File[0]
+	if mimetype.Name != "mimetype" || mimetype.Method != zip.Store {
+		t.Errorf("got %s with method %d as the first file, want an uncompressed mimetype", mimetype.Name, mimetype.Method)
+	}
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name], err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(file.Name, ".xml") || strings.HasSuffix(file.Name, ".xhtml") || strings.HasSuffix(file.Name, ".opf") {
+			if err := wellFormed(files[file.Name]); err != nil {
+				t.Errorf("%s: %v", file.Name, err)
+			}
+		}
+	}
+	if string(files["mimetype"]) != "application/epub+zip" {
+		t.Errorf("got mimetype %q", files["mimetype"])
+	}
+
+	var pkg opf
+	if err := xml.Unmarshal(files["OEBPS/content.opf"], &pkg); err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Title != "The Story" {
+		t.Errorf("got title %q, want %q", pkg.Title, "The Story")
+	}
+	ids := make(map[string]bool)
+	var nav, cover bool
+	for _, item := range pkg.Manifest {
+		ids[item.ID] = true
+		if _, ok := files["OEBPS/"+item.Href]; !ok {
+			t.Errorf("manifest item %s: %s is not in the archive", item.ID, item.Href)
+		}
+		nav = nav || item.Properties == "nav"
+		cover = cover || item.Properties == "cover-image" && path.Ext(item.Href) == ".png"
+	}
+	if !nav || !cover {
+		t.Errorf("got nav %t and PNG cover %t in the manifest, want both", nav, cover)
+	}
+	var spine []string
+	for _, item := range pkg.Spine {
+		if !ids[item.IDRef] {
+			t.Errorf("spine item %s is not in the manifest", item.IDRef)
+		}
+		spine = append(spine, item.IDRef)
+	}
+	if want := []string{"cover", "nav", "chapter-1", "chapter-2"}; !slices.Equal(spine, want) {
+		t.Errorf("got spine %q, want %q", spine, want)
+	}
+
+	for _, want := range []string{`href="chapter-001.xhtml">Chapter 1<`, `href="chapter-002.xhtml">Chapter 2 &lt;end&gt;<`} {
+		if !bytes.Contains(files["OEBPS/nav.xhtml"], []byte(want)) {
+			t.Errorf("nav.xhtml does not contain %s:\n%s", want, files["OEBPS/nav.xhtml"])
+		}
+	}
+	if want := "<em>dark</em> night &amp; cold."; !bytes.Contains(files["OEBPS/chapter-001.xhtml"], []byte(want)) {
+		t.Errorf("chapter-001.xhtml does not contain %s:\n%s", want, files["OEBPS/chapter-001.xhtml"])
+	}
+}
+
+// wellFormed returns an error if data is not well-formed XML.
+func wellFormed(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := inkbunny.NewBook(story()...).WriteMarkdown(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# The Story
+
+*by writer*
+
+A **long** story
+
+## Chapter 1
+
+It was a *dark* night & cold.
+
+## Chapter 2 \<end\>
+
+The end.
+
+---
+
+Thanks for reading
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,278 @@
+package inkbunny
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// SearchFieldError reports a SubmissionSearchRequest field that the API would reject or ignore.
+type SearchFieldError struct {
+	Field  string // Name of the query parameter, e.g. "orderby".
+	Reason string
+}
+
+func (e *SearchFieldError) Error() string {
+	return fmt.Sprintf("invalid search request: %s: %s", e.Field, e.Reason)
+}
+
+// Validate reports the conflicts between the fields of a SubmissionSearchRequest, joined with errors.Join.
+// Each conflict is a *SearchFieldError. Mode 1 parameters are not checked when an RID is set, since they are ignored.
+func (r SubmissionSearchRequest) Validate() error {
+	var errs []error
+	invalid := func(field, format string, args ...any) {
+		errs = append(errs, &SearchFieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if r.SubmissionsPerPage < 0 || r.SubmissionsPerPage > 100 {
+		invalid("submissions_per_page", "must be from 0 to 100, got %d", r.SubmissionsPerPage)
+	}
+	if r.Page != 0 && r.Page < 1 {
+		invalid("page", "must be 1 or more, got %d", r.Page)
+	}
+	if r.RID != "" {
+		return errors.Join(errs...)
+	}
+
+	switch r.OrderBy {
+	case "", types.OrderByCreateDatetime, types.OrderByLastFileUpdateDatetime, types.OrderByViews,
+		types.OrderByTotalPrint, types.OrderByTotalDigital, types.OrderByTotalSales, types.OrderByUsername, types.OrderByFavs:
+	case types.OrderByUnreadDatetime, types.OrderByUnreadDatetimeReverse:
+		if !r.UnreadSubmissions {
+			invalid("orderby", "%q can only be used with unread_submissions", r.OrderBy)
+		}
+	case types.OrderByFavDatetime, types.OrderByFavStars:
+		if r.FavsUserID == 0 {
+			invalid("orderby", "%q can only be used with favs_user_id", r.OrderBy)
+		}
+	case types.OrderByPoolOrder:
+		if r.PoolID == 0 {
+			invalid("orderby", "%q can only be used with pool_id", r.OrderBy)
+		}
+	default:
+		invalid("orderby", "unknown order %q", r.OrderBy)
+	}
+
+	if r.Text != "" && r.KeywordID == 0 {
+		searchKeywords := r.Keywords == nil || bool(*r.Keywords)
+		if !searchKeywords && !isYes(r.Title) && !isYes(r.Description) && !isYes(r.MD5) {
+			invalid("text", "at least one of keywords, title, description or md5 must be set to yes")
+		}
+	}
+	switch r.StringJoinType {
+	case "", types.JoinTypeAnd, types.JoinTypeOr, types.JoinTypeExact:
+	default:
+		invalid("string_join_type", "must be %q, %q or %q, got %q", types.JoinTypeAnd, types.JoinTypeOr, types.JoinTypeExact, r.StringJoinType)
+	}
+	switch r.FieldJoinType {
+	case "", types.FieldJoinTypeAnd, types.FieldJoinTypeOr:
+	default:
+		invalid("field_join_type", "must be %q or %q, got %q", types.FieldJoinTypeAnd, types.FieldJoinTypeOr, r.FieldJoinType)
+	}
+	switch r.Scraps {
+	case "", ScrapsBoth, ScrapsNo, ScrapsOnly:
+	default:
+		invalid("scraps", "must be %q, %q or %q, got %q", ScrapsBoth, ScrapsNo, ScrapsOnly, r.Scraps)
+	}
+	for _, t := range r.Type {
+		if t < SubmissionTypePicturePinup || t > SubmissionTypePhotography {
+			invalid("type", "unknown submission type %d", t)
+		}
+	}
+	if r.CountLimit != 0 && (r.CountLimit < 1 || r.CountLimit > 50000) {
+		invalid("count_limit", "must be from 1 to 50000, got %d", r.CountLimit)
+	}
+	if r.DaysLimit != 0 && r.DaysLimit < 1 {
+		invalid("dayslimit", "must be 1 or more, got %d", r.DaysLimit)
+	}
+	return errors.Join(errs...)
+}
+
+func isYes(b *types.BooleanYN) bool {
+	return b != nil && bool(*b)
+}
+
+// SearchBuilder builds a SubmissionSearchRequest. Use NewSearch to create one.
+//
+//	req, err := inkbunny.NewSearch().
+//		Text("fox dragon").
+//		SearchTitle(true).
+//		OrderBy(types.OrderByViews).
+//		PerPage(100).
+//		Build()
+type SearchBuilder struct {
+	req SubmissionSearchRequest
+}
+
+// NewSearch returns an empty SearchBuilder.
+func NewSearch() *SearchBuilder {
+	return new(SearchBuilder)
+}
+
+// Build validates and returns the request. The request is returned even if it is invalid.
+func (b *SearchBuilder) Build() (SubmissionSearchRequest, error) {
+	return b.req, b.req.Validate()
+}
+
+// SID sets the session ID. It can be left blank when searching from a User.
+func (b *SearchBuilder) SID(sid string) *SearchBuilder {
+	b.req.SID = sid
+	return b
+}
+
+// Text searches for text in the chosen fields, joining the words with "and" unless StringJoin is set.
+func (b *SearchBuilder) Text(text string) *SearchBuilder {
+	b.req.Text = text
+	return b
+}
+
+// StringJoin sets how the words of Text are joined.
+func (b *SearchBuilder) StringJoin(join types.JoinType) *SearchBuilder {
+	b.req.StringJoinType = join
+	return b
+}
+
+// FieldJoin sets whether Text must be found in any or all of the chosen fields.
+func (b *SearchBuilder) FieldJoin(join types.FieldJoinType) *SearchBuilder {
+	b.req.FieldJoinType = join
+	return b
+}
+
+// SearchKeywords sets whether Text is searched in keywords. This is on by default.
+func (b *SearchBuilder) SearchKeywords(search bool) *SearchBuilder {
+	b.req.Keywords = types.Address(types.BooleanYN(search))
+	return b
+}
+
+// SearchTitle sets whether Text is searched in titles.
+func (b *SearchBuilder) SearchTitle(search bool) *SearchBuilder {
+	b.req.Title = types.Address(types.BooleanYN(search))
+	return b
+}
+
+// SearchDescription sets whether Text is searched in descriptions and stories.
+func (b *SearchBuilder) SearchDescription(search bool) *SearchBuilder {
+	b.req.Description = types.Address(types.BooleanYN(search))
+	return b
+}
+
+// SearchMD5 sets whether Text is searched in the MD5 hashes of files.
+func (b *SearchBuilder) SearchMD5(search bool) *SearchBuilder {
+	b.req.MD5 = types.Address(types.BooleanYN(search))
+	return b
+}
+
+// KeywordID searches for a keyword by ID, overriding Text.
+func (b *SearchBuilder) KeywordID(id types.IntString) *SearchBuilder {
+	b.req.KeywordID = id
+	return b
+}
+
+// Username limits results to submissions owned by a user.
+func (b *SearchBuilder) Username(username string) *SearchBuilder {
+	b.req.Username = username
+	return b
+}
+
+// UserID limits results to submissions owned by a user.
+func (b *SearchBuilder) UserID(id types.IntString) *SearchBuilder {
+	b.req.UserID = id
+	return b
+}
+
+// FavoritesOf limits results to the favorites of a user.
+func (b *SearchBuilder) FavoritesOf(userID types.IntString) *SearchBuilder {
+	b.req.FavsUserID = userID
+	return b
+}
+
+// Unread limits results to the unread submissions of the logged-in user.
+func (b *SearchBuilder) Unread() *SearchBuilder {
+	b.req.UnreadSubmissions = types.Yes
+	return b
+}
+
+// Types limits results to the given submission types.
+func (b *SearchBuilder) Types(t ...SubmissionType) *SearchBuilder {
+	b.req.Type = append(b.req.Type, t...)
+	return b
+}
+
+// Pool limits results to the submissions of a pool.
+func (b *SearchBuilder) Pool(id types.IntString) *SearchBuilder {
+	b.req.PoolID = id
+	return b
+}
+
+// Scraps sets how submissions in Scraps are returned.
+func (b *SearchBuilder) Scraps(scraps Scraps) *SearchBuilder {
+	b.req.Scraps = scraps
+	return b
+}
+
+// DaysLimit limits results to submissions uploaded in the last days.
+func (b *SearchBuilder) DaysLimit(days int) *SearchBuilder {
+	b.req.DaysLimit = types.IntString(days)
+	return b
+}
+
+// OrderBy sets the order of the results.
+func (b *SearchBuilder) OrderBy(order types.OrderBy) *SearchBuilder {
+	b.req.OrderBy = order
+	return b
+}
+
+// Random sorts the results randomly, after the other orders and limits are applied.
+func (b *SearchBuilder) Random() *SearchBuilder {
+	b.req.Random = types.Yes
+	return b
+}
+
+// CountLimit limits the number of results, from 1 to 50000.
+func (b *SearchBuilder) CountLimit(limit int) *SearchBuilder {
+	b.req.CountLimit = types.IntString(limit)
+	return b
+}
+
+// PerPage sets the number of submissions per page, from 0 to 100.
+func (b *SearchBuilder) PerPage(n int) *SearchBuilder {
+	b.req.SubmissionsPerPage = types.IntString(n)
+	return b
+}
+
+// Page sets the page of results to return, starting from 1.
+func (b *SearchBuilder) Page(page int) *SearchBuilder {
+	b.req.Page = types.IntString(page)
+	return b
+}
+
+// GetRID returns a Results ID to page through the results without running the search again.
+func (b *SearchBuilder) GetRID() *SearchBuilder {
+	b.req.GetRID = types.Yes
+	return b
+}
+
+// RID pages through an existing results set (Mode 2). The other search conditions are ignored by the API.
+func (b *SearchBuilder) RID(rid string) *SearchBuilder {
+	b.req.RID = rid
+	return b
+}
+
+// KeywordsList returns the top keywords of the submissions on the current page.
+func (b *SearchBuilder) KeywordsList() *SearchBuilder {
+	b.req.KeywordsList = types.Yes
+	return b
+}
+
+// NoSubmissions skips returning the submissions, e.g. to only count results.
+func (b *SearchBuilder) NoSubmissions() *SearchBuilder {
+	b.req.NoSubmissions = types.Yes
+	return b
+}
+
+// SubmissionIDsOnly only returns the IDs of the submissions.
+func (b *SearchBuilder) SubmissionIDsOnly() *SearchBuilder {
+	b.req.SubmissionIDsOnly = types.Yes
+	return b
+}
@@ -0,0 +1,64 @@
+package inkbunny_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestSearchBuilder(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		search *inkbunny.SearchBuilder
+		fields []string // fields is the Field of every SearchFieldError, in order.
+	}{
+		{"empty", inkbunny.NewSearch(), nil},
+		{"valid", inkbunny.NewSearch().Text("fox").SearchTitle(true).OrderBy(types.OrderByViews).PerPage(100).Page(2), nil},
+		{"per page", inkbunny.NewSearch().PerPage(101), []string{"submissions_per_page"}},
+		{"negative per page", inkbunny.NewSearch().PerPage(-1), []string{"submissions_per_page"}},
+		{"page", inkbunny.NewSearch().Page(-1), []string{"page"}},
+		{"page 0", inkbunny.NewSearch().Page(0), nil},
+		{"page 1", inkbunny.NewSearch().Page(1), nil},
+		{"unread order", inkbunny.NewSearch().OrderBy(types.OrderByUnreadDatetime), []string{"orderby"}},
+		{"unread", inkbunny.NewSearch().Unread().OrderBy(types.OrderByUnreadDatetime), nil},
+		{"favorites order", inkbunny.NewSearch().OrderBy(types.OrderByFavStars), []string{"orderby"}},
+		{"favorites", inkbunny.NewSearch().FavoritesOf(1).OrderBy(types.OrderByFavStars), nil},
+		{"pool order", inkbunny.NewSearch().OrderBy(types.OrderByPoolOrder), []string{"orderby"}},
+		{"pool", inkbunny.NewSearch().Pool(1).OrderBy(types.OrderByPoolOrder), nil},
+		{"unknown order", inkbunny.NewSearch().OrderBy("size"), []string{"orderby"}},
+		{"no text fields", inkbunny.NewSearch().Text("fox").SearchKeywords(false), []string{"text"}},
+		{"text in keywords", inkbunny.NewSearch().Text("fox"), nil},
+		{"keyword ID", inkbunny.NewSearch().Text("fox").SearchKeywords(false).KeywordID(1), nil},
+		{"string join", inkbunny.NewSearch().StringJoin("xor"), []string{"string_join_type"}},
+		{"field join", inkbunny.NewSearch().FieldJoin("xor"), []string{"field_join_type"}},
+		{"scraps", inkbunny.NewSearch().Scraps("maybe"), []string{"scraps"}},
+		{"type", inkbunny.NewSearch().Types(inkbunny.SubmissionTypeComic, inkbunny.SubmissionType(15)), []string{"type"}},
+		{"count limit", inkbunny.NewSearch().CountLimit(50001), []string{"count_limit"}},
+		{"days", inkbunny.NewSearch().DaysLimit(-1), []string{"dayslimit"}},
+		{"several", inkbunny.NewSearch().PerPage(200).Page(-2).OrderBy(types.OrderByPoolOrder).DaysLimit(-1), []string{"submissions_per_page", "page", "orderby", "dayslimit"}},
+		{"RID ignores mode 1", inkbunny.NewSearch().RID("abc").OrderBy(types.OrderByPoolOrder).Scraps("maybe"), nil},
+		{"RID checks pages", inkbunny.NewSearch().RID("abc").Page(-1), []string{"page"}},
+	} {
+		request, err := test.search.Build()
+		var fields []string
+		if err != nil {
+			for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var fieldErr *inkbunny.SearchFieldError
+				if !errors.As(err, &fieldErr) {
+					t.Errorf("%s: got %T, want a *SearchFieldError", test.name, err)
+					continue
+				}
+				fields = append(fields, fieldErr.Field)
+			}
+		}
+		if !slices.Equal(fields, test.fields) {
+			t.Errorf("%s: got errors for %q, want %q: %v", test.name, fields, test.fields, err)
+		}
+		if validated := request.Validate(); (validated == nil) != (err == nil) {
+			t.Errorf("%s: Validate returned %v, Build returned %v", test.name, validated, err)
+		}
+	}
+}
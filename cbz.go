@@ -0,0 +1,233 @@
+package inkbunny
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// ErrNoFiles is returned when exporting submissions that have no files.
+var ErrNoFiles = errors.New("no files to export")
+
+// ComicInfo is the ComicInfo.xml metadata of a CBZ archive, read by most comic readers.
+// Use NewComicInfo to fill it in from submissions.
+type ComicInfo struct {
+	XMLName   xml.Name    `xml:"ComicInfo"`
+	Title     string      `xml:"Title,omitempty"`
+	Series    string      `xml:"Series,omitempty"`
+	Count     int         `xml:"Count,omitempty"`
+	Summary   string      `xml:"Summary,omitempty"`
+	Year      int         `xml:"Year,omitempty"`
+	Month     int         `xml:"Month,omitempty"`
+	Day       int         `xml:"Day,omitempty"`
+	Writer    string      `xml:"Writer,omitempty"`
+	Penciller string      `xml:"Penciller,omitempty"`
+	Publisher string      `xml:"Publisher,omitempty"`
+	Genre     string      `xml:"Genre,omitempty"`
+	Tags      string      `xml:"Tags,omitempty"`
+	Web       string      `xml:"Web,omitempty"`
+	PageCount int         `xml:"PageCount,omitempty"`
+	AgeRating string      `xml:"AgeRating,omitempty"`
+	Pages     []ComicPage `xml:"Pages>Page,omitempty"`
+}
+
+// ComicPage describes a page of a ComicInfo.
+type ComicPage struct {
+	Image     int    `xml:"Image,attr"`
+	Type      string `xml:"Type,attr,omitempty"`
+	ImageSize int64  `xml:"ImageSize,attr,omitempty"`
+}
+
+// NewComicInfo returns the ComicInfo of the submissions, such as a single comic or every chapter of a pool.
+//
+// The title, description and date are those of the first submission. When the submissions share a pool,
+// it is used as the Series, and when there is more than one submission, the name and description of the pool
+// replace the title and description. Artists, keywords and types of every submission are listed, and the
+// AgeRating is the highest rating among them.
+func NewComicInfo(submissions ...SubmissionDetails) ComicInfo {
+	if len(submissions) == 0 {
+		return ComicInfo{}
+	}
+	first := submissions[0]
+	info := ComicInfo{
+		Title:     first.Title,
+		Summary:   first.Description,
+		Publisher: "Inkbunny",
+	}
+	if !first.CreateDateSystem.IsZero() {
+		date := first.CreateDateSystem.UTC()
+		info.Year, info.Month, info.Day = date.Year(), int(date.Month()), date.Day()
+	}
+	if pool, ok := commonPool(submissions); ok {
+		info.Series = pool.Name
+		info.Count = int(pool.Count)
+		if len(submissions) > 1 {
+			info.Title = pool.Name
+			info.Summary = cmp.Or(pool.Description, info.Summary)
+		}
+	}
+
+	var artists, keywords, genres, links []string
+	rating := types.RatingGeneral
+	for _, submission := range submissions {
+		artists = appendUnique(artists, submission.Username)
+		for _, keyword := range submission.Keywords {
+			keywords = appendUnique(keywords, keyword.KeywordName)
+		}
+		genres = appendUnique(genres, submission.TypeName)
+		links = append(links, submission.URL().String())
+		rating = max(rating, submission.Rating())
+	}
+	info.Writer = strings.Join(artists, ", ")
+	info.Penciller = info.Writer
+	info.Genre = strings.Join(genres, ", ")
+	info.Tags = strings.Join(keywords, ", ")
+	info.Web = strings.Join(links, " ")
+	switch rating {
+	case types.RatingAdult:
+		info.AgeRating = "Adults Only 18+"
+	case types.RatingMature:
+		info.AgeRating = "Mature 17+"
+	default:
+		info.AgeRating = "Everyone"
+	}
+	return info
+}
+
+// commonPool returns the first pool of the first submission that every submission is in.
+func commonPool(submissions []SubmissionDetails) (Pool, bool) {
+	for _, pool := range submissions[0].Pools {
+		missing := slices.ContainsFunc(submissions[1:], func(s SubmissionDetails) bool {
+			return !slices.ContainsFunc(s.Pools, func(p Pool) bool { return p.PoolID == pool.PoolID })
+		})
+		if !missing {
+			return pool, true
+		}
+	}
+	return Pool{}, false
+}
+
+func appendUnique(values []string, value string) []string {
+	if value == "" || slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// WriteCBZ writes a CBZ archive of downloaded files, with one page per result in order, followed by a ComicInfo.xml.
+// Pages are named by their position, e.g. "001.png", so that readers keep their order.
+// PageCount and Pages of the ComicInfo are filled in when they are not set.
+// It returns an error if any result has an error, since the archive would be missing a page.
+func WriteCBZ(w io.Writer, info ComicInfo, results ...DownloadResult) error {
+	for _, result := range results {
+		if result.Err != nil {
+			return fmt.Errorf("missing page %s: %w", result.Path, result.Err)
+		}
+	}
+	archive := zip.NewWriter(w)
+	width := len(fmt.Sprint(len(results)))
+	var pages []ComicPage
+	for i, result := range results {
+		name := fmt.Sprintf("%0*d%s", width, i+1, strings.ToLower(filepath.Ext(result.Path)))
+		size, err := addFile(archive, name, result.Path)
+		if err != nil {
+			return fmt.Errorf("error adding page %s: %w", result.Path, err)
+		}
+		page := ComicPage{Image: i, ImageSize: size}
+		if i == 0 {
+			page.Type = "FrontCover"
+		}
+		pages = append(pages, page)
+	}
+	if info.PageCount == 0 {
+		info.PageCount = len(results)
+	}
+	if info.Pages == nil {
+		info.Pages = pages
+	}
+
+	out, err := archive.Create("ComicInfo.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(info); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// addFile stores the file at name in the archive without compressing it, since images are already compressed.
+func addFile(archive *zip.Writer, name, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	out, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(out, f)
+}
+
+// CBZ downloads the files of the submissions and packages them into a CBZ archive at name, with a ComicInfo.xml
+// from NewComicInfo. Pages follow the order of the submissions, then SubmissionFileOrder.
+//
+//	submissions, err := user.PoolSubmissions(poolID)
+//	if err != nil {
+//		return err
+//	}
+//	err = client.NewDownloader("downloads").CBZ("pool.cbz", submissions...)
+//
+// The files are kept in the directory of the Downloader, so that they are skipped by the next export.
+func (d *Downloader) CBZ(name string, submissions ...SubmissionDetails) error {
+	return d.CBZContext(d.client.Context(), name, submissions...)
+}
+
+// CBZContext is like Downloader.CBZ but uses ctx for the requests.
+func (d *Downloader) CBZContext(ctx context.Context, name string, submissions ...SubmissionDetails) error {
+	submissions = slices.Clone(submissions)
+	for i := range submissions {
+		submissions[i].Files = slices.Clone(submissions[i].Files)
+		slices.SortStableFunc(submissions[i].Files, func(a, b File) int {
+			return cmp.Compare(a.SubmissionFileOrder, b.SubmissionFileOrder)
+		})
+	}
+	results, err := d.DownloadContext(ctx, submissions...)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return ErrNoFiles
+	}
+
+	temp := name + ".tmp"
+	out, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+	err = WriteCBZ(out, NewComicInfo(submissions...), results...)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return os.Rename(temp, name)
+}
@@ -0,0 +1,107 @@
+package inkbunny_test
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestPoolCBZ(t *testing.T) {
+	server, _ := newServer(t, 1)
+	pool := inkbunny.Pool{PoolID: 7, Name: "The Comic", Description: "A comic", Count: 3}
+	start := time.Now().Add(-time.Hour)
+	// Chapters are added out of order, so that their IDs do not follow the pool order.
+	for _, chapter := range []int{2, 1, 3} {
+		id := server.AddSubmission(inkbunny.SubmissionDetails{
+			SubmissionBasic: inkbunny.SubmissionBasic{
+				Username:         "artist",
+				Title:            fmt.Sprintf("Chapter %d", chapter),
+				CreateDateSystem: types.Time{Time: start.Add(time.Duration(chapter) * time.Minute)},
+			},
+			Files: []inkbunny.File{{FileName: "page.png"}, {FileName: "page.png"}},
+			Pools: []inkbunny.Pool{pool},
+		})
+		submission, _ := server.Submission(id)
+		for page, file := range submission.Files {
+			server.SetFileContent(file.FileID, fmt.Appendf(nil, "chapter %d page %d", chapter, page+1))
+		}
+	}
+	user := login(t, server.Client())
+
+	submissions, err := user.PoolSubmissions(pool.PoolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, submission := range submissions {
+		titles = append(titles, submission.Title)
+	}
+	if want := []string{"Chapter 1", "Chapter 2", "Chapter 3"}; !slices.Equal(titles, want) {
+		t.Fatalf("got %q, want %q", titles, want)
+	}
+
+	name := filepath.Join(t.TempDir(), "comic.cbz")
+	if err := user.Client().NewDownloader(t.TempDir()).CBZ(name, submissions...); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	var names, pages []string
+	var info inkbunny.ComicInfo
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file.Name == "ComicInfo.xml" {
+			if err := xml.Unmarshal(data, &info); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if file.Method != zip.Store {
+			t.Errorf("%s: got method %d, want the page stored", file.Name, file.Method)
+		}
+		pages = append(pages, string(data))
+	}
+	if want := []string{"1.png", "2.png", "3.png", "4.png", "5.png", "6.png", "ComicInfo.xml"}; !slices.Equal(names, want) {
+		t.Errorf("got files %q, want %q", names, want)
+	}
+	var want []string
+	for chapter := 1; chapter <= 3; chapter++ {
+		for page := 1; page <= 2; page++ {
+			want = append(want, fmt.Sprintf("chapter %d page %d", chapter, page))
+		}
+	}
+	if !slices.Equal(pages, want) {
+		t.Errorf("got pages %q, want %q", pages, want)
+	}
+
+	if info.Title != "The Comic" || info.Series != "The Comic" || info.Count != 3 || info.Summary != "A comic" {
+		t.Errorf("got title %q, series %q, count %d and summary %q", info.Title, info.Series, info.Count, info.Summary)
+	}
+	if info.Writer != "artist" || info.PageCount != 6 || len(info.Pages) != 6 || info.Pages[0].Type != "FrontCover" {
+		t.Errorf("got writer %q, %d pages and %+v", info.Writer, info.PageCount, info.Pages)
+	}
+	if year, month, day := start.Add(time.Minute).UTC().Date(); info.Year != year || info.Month != int(month) || info.Day != day {
+		t.Errorf("got date %d-%d-%d, want the date of the first chapter", info.Year, info.Month, info.Day)
+	}
+}
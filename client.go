@@ -6,17 +6,24 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
+	"github.com/ellypaws/inkbunny/types"
 	"github.com/ellypaws/inkbunny/utils"
 )
 
 var DefaultClient = NewClient()
 
 type Client struct {
-	ctx    context.Context
-	client *http.Client
+	ctx     context.Context
+	client  *http.Client
+	baseURL *url.URL
+
+	credentials Credentials
+	retry       RetryPolicy
+
+	limiter          Limiter
+	endpointLimiters map[string]Limiter
 }
 
 func (c *Client) Get() *Client {
@@ -39,6 +46,9 @@ func NewClient(opts ...func(*Client)) *Client {
 			Timeout: 5 * time.Minute,
 		}
 	}
+	if c.baseURL == nil {
+		c.baseURL = defaultBaseURL
+	}
 	return c
 }
 
@@ -54,10 +64,30 @@ func WithContext(ctx context.Context) func(*Client) {
 	}
 }
 
+// WithBaseURL sets the scheme, host and optional path prefix used for every request made by the Client.
+// This can point to a staging mirror, a recording proxy or an httptest.Server instead of DefaultBaseURL.
+//
+//	server := httptest.NewServer(handler)
+//	base, _ := url.Parse(server.URL)
+//	client := NewClient(WithBaseURL(base))
+func WithBaseURL(base *url.URL) func(*Client) {
+	return func(c *Client) {
+		c.baseURL = base
+	}
+}
+
 func (c *Client) SetContext(ctx context.Context) {
 	c.ctx = ctx
 }
 
+// Context returns the context.Context used by methods without a ctx parameter.
+func (c *Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 func (c *Client) SetClient(client *http.Client) {
 	c.client = client
 }
@@ -66,6 +96,18 @@ func (c *Client) SetTimeout(timeout time.Duration) {
 	c.client.Timeout = timeout
 }
 
+func (c *Client) SetBaseURL(base *url.URL) {
+	c.baseURL = base
+}
+
+// BaseURL returns the base URL used for requests, which defaults to DefaultBaseURL.
+func (c *Client) BaseURL() *url.URL {
+	if c.baseURL == nil {
+		return defaultBaseURL
+	}
+	return c.baseURL
+}
+
 const (
 	MimeTypeJSON  = "application/json"
 	MimeTypeForm  = "multipart/form-data"
@@ -74,49 +116,148 @@ const (
 
 // PostDecode sends a POST request to the given URL with the provided data.
 // It automatically reads the [http.Response.Body], checks for errors and decodes into T.
-// It calls Client.PostForm and then utils.ParseResponse[T].
+// It sends the request like Client.PostForm and then calls utils.ParseResponse[T],
+// retrying both transport and API errors according to the Client RetryPolicy.
 func PostDecode[T any](c *Client, url *url.URL, data any) (T, error) {
-	response, err := c.PostForm(url, data)
-	if err != nil {
-		var t T
-		return t, err
-	}
-	return utils.ParseResponse[T](response)
+	return PostDecodeContext[T](c.Context(), c, url, data)
+}
+
+// PostDecodeContext is like PostDecode but uses ctx for the request, the rate limiters and the retry backoff.
+func PostDecodeContext[T any](ctx context.Context, c *Client, url *url.URL, data any) (T, error) {
+	var t T
+	err := c.roundTrip(ctx, url, data, func(response *http.Response, _ bool) error {
+		var err error
+		t, err = utils.ParseResponse[T](response)
+		return err
+	})
+	return t, err
 }
 
 // PostForm sends a POST request to the specified URL with the provided data and returns the HTTP response or an error.
 // The method determines the appropriate content type and body format based on the type of the data parameter.
 // Passing in a []byte or any type that implements io.Reader assumes the Content-Type is of MimeTypeJSON.
+// URLs pointing to DefaultBaseURL are rewritten to the Client base URL.
+// Responses with a 5xx or 429 status are retried according to the Client RetryPolicy.
 func (c *Client) PostForm(u *url.URL, data any) (*http.Response, error) {
+	return c.PostFormContext(c.Context(), u, data)
+}
+
+// PostFormContext is like Client.PostForm but uses ctx for the request, the rate limiters and the retry backoff.
+func (c *Client) PostFormContext(ctx context.Context, u *url.URL, data any) (*http.Response, error) {
+	var response *http.Response
+	err := c.roundTrip(ctx, u, data, func(r *http.Response, final bool) error {
+		if !final && (r.StatusCode >= http.StatusInternalServerError || r.StatusCode == http.StatusTooManyRequests) {
+			_, _ = io.Copy(io.Discard, r.Body)
+			r.Body.Close()
+			return &types.APIError{
+				Code:       types.ErrUnexpectedStatus,
+				Message:    r.Status,
+				Endpoint:   r.Request.URL.Path,
+				StatusCode: r.StatusCode,
+			}
+		}
+		response = r
+		return nil
+	})
+	return response, err
+}
+
+// roundTrip sends data to u and passes the response to handle, which is responsible for closing the body.
+// Errors from sending the request or from handle are retried according to the Client RetryPolicy.
+// final is true when no further attempt will be made regardless of the error.
+func (c *Client) roundTrip(ctx context.Context, u *url.URL, data any, handle func(response *http.Response, final bool) error) error {
+	u = c.rebase(u)
+	body, contentType, err := encodeBody(u, data)
+	if err != nil {
+		return err
+	}
+	replayable := body.rewind != nil
+	for attempt := 1; ; attempt++ {
+		final := !replayable || attempt >= c.retry.MaxAttempts
+		err := c.attempt(ctx, u, body.reader, contentType, func(response *http.Response) error {
+			return handle(response, final)
+		})
+		if err == nil || !replayable || !c.retry.shouldRetry(u, attempt, err) {
+			return err
+		}
+		if err := sleep(ctx, c.retry.Backoff(attempt)); err != nil {
+			return err
+		}
+		if err := body.rewind(); err != nil {
+			return err
+		}
+	}
+}
+
+// attempt sends a single request.
+func (c *Client) attempt(ctx context.Context, u *url.URL, body io.Reader, contentType string, handle func(*http.Response) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	response, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	return handle(response)
+}
+
+// Do sends an HTTP request using the Client http.Client, after waiting for the Client rate limiters.
+// It does not retry nor check the response for errors.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := c.wait(req.Context(), req.URL); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+// requestBody is a request body that can optionally be rewound for another attempt.
+type requestBody struct {
+	reader io.Reader
+	rewind func() error
+}
+
+// encodeBody determines the body and content type of data.
+// Passing url.Values also appends them to the query of u.
+func encodeBody(u *url.URL, data any) (requestBody, string, error) {
 	contentType := MimeTypeQuery
-	var body io.Reader
+	var body []byte
 	switch d := data.(type) {
 	case nil:
+		return requestBody{rewind: func() error { return nil }}, contentType, nil
 	case []byte:
-		body = bytes.NewReader(d)
+		body = d
 		contentType = MimeTypeJSON
 	case io.Reader:
-		body = d
 		contentType = MimeTypeJSON
+		seeker, ok := d.(io.Seeker)
+		if !ok {
+			return requestBody{reader: d}, contentType, nil
+		}
+		return requestBody{reader: d, rewind: func() error {
+			_, err := seeker.Seek(0, io.SeekStart)
+			return err
+		}}, contentType, nil
 	case url.Values:
 		if u.RawQuery != "" {
 			u.RawQuery += "&" + d.Encode()
 		} else {
 			u.RawQuery = d.Encode()
 		}
-		body = strings.NewReader(u.RawQuery)
+		body = []byte(u.RawQuery)
 	default:
-		var err error
-		body, contentType, err = utils.StructToMultipartForm(data)
+		buffer, multipartType, err := utils.StructToMultipartForm(data)
 		if err != nil {
-			return nil, err
+			return requestBody{}, "", err
 		}
+		body = buffer.Bytes()
+		contentType = multipartType
 	}
-	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, u.String(), body)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", contentType)
-
-	return c.client.Do(req)
+	reader := bytes.NewReader(body)
+	return requestBody{reader: reader, rewind: func() error {
+		_, err := reader.Seek(0, io.SeekStart)
+		return err
+	}}, contentType, nil
 }
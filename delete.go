@@ -1,6 +1,7 @@
 package inkbunny
 
 import (
+	"context"
 	"net/url"
 	"strconv"
 
@@ -19,16 +20,30 @@ type ReorderFileResponse struct {
 }
 
 func (u *User) DeleteFile(id int) (DeleteFileResponse, error) {
-	if u.SID == "" {
+	return u.DeleteFileContext(u.Client().Context(), id)
+}
+
+// DeleteFileContext is like User.DeleteFile but uses ctx for the request.
+func (u *User) DeleteFileContext(ctx context.Context, id int) (DeleteFileResponse, error) {
+	if u.sid() == "" {
 		return DeleteFileResponse{FileID: types.IntString(id)}, ErrNotLoggedIn
 	}
-	return PostDecode[DeleteFileResponse](u.Client(), ApiUrl("delfile"), url.Values{"sid": {u.SID}, "file_id": {strconv.Itoa(id)}})
+	return withSession(ctx, u, u.sid(), func(sid string) (DeleteFileResponse, error) {
+		return PostDecodeContext[DeleteFileResponse](ctx, u.Client(), u.Client().ApiUrl("delfile"), url.Values{"sid": {sid}, "file_id": {strconv.Itoa(id)}})
+	})
 }
 
 func (u *User) ReorderFile(id int, position int) (ReorderFileResponse, error) {
-	if u.SID == "" {
+	return u.ReorderFileContext(u.Client().Context(), id, position)
+}
+
+// ReorderFileContext is like User.ReorderFile but uses ctx for the request.
+func (u *User) ReorderFileContext(ctx context.Context, id int, position int) (ReorderFileResponse, error) {
+	if u.sid() == "" {
 		return ReorderFileResponse{FileID: types.IntString(id), NewPosition: types.IntString(position)}, ErrNotLoggedIn
 	}
-	values := url.Values{"sid": {u.SID}, "file_id": {strconv.Itoa(id)}, "newpos": {strconv.Itoa(position)}}
-	return PostDecode[ReorderFileResponse](u.Client(), ApiUrl("reorderfile"), values)
+	return withSession(ctx, u, u.sid(), func(sid string) (ReorderFileResponse, error) {
+		values := url.Values{"sid": {sid}, "file_id": {strconv.Itoa(id)}, "newpos": {strconv.Itoa(position)}}
+		return PostDecodeContext[ReorderFileResponse](ctx, u.Client(), u.Client().ApiUrl("reorderfile"), values)
+	})
 }
@@ -0,0 +1,377 @@
+package inkbunny
+
+import (
+	"cmp"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// ErrMD5Mismatch is returned by a Downloader when a downloaded file does not match the MD5 hash sent by the API.
+var ErrMD5Mismatch = errors.New("md5 mismatch")
+
+// Size is the size of a file to download.
+type Size string
+
+const (
+	SizeFull    Size = "full"    // The full size file, verified with FileMD5.FullFileMD5.
+	SizeScreen  Size = "screen"  // The screen size file, verified with FileMD5.LargeFileMD5.
+	SizePreview Size = "preview" // The preview size file, verified with FileMD5.SmallFileMD5.
+)
+
+// URL returns the URL of the file at this size.
+func (s Size) URL(f File) string {
+	switch s {
+	case SizeScreen:
+		return f.FileURLScreen
+	case SizePreview:
+		return f.FileURLPreview
+	}
+	return f.FileURLFull
+}
+
+// MD5 returns the MD5 hash of the file at this size, as sent by the API.
+func (s Size) MD5(f File) string {
+	switch s {
+	case SizeScreen:
+		return f.LargeFileMD5
+	case SizePreview:
+		return f.SmallFileMD5
+	}
+	return f.FullFileMD5
+}
+
+// DownloadResult is the outcome of downloading a single File.
+type DownloadResult struct {
+	Submission SubmissionDetails
+	File       File
+	Path       string // Path is where the file was saved.
+	Skipped    bool   // Skipped is true if the file was already present and matched its MD5 hash.
+	Resumed    bool   // Resumed is true if a partial download was continued with a Range request.
+	Bytes      int64  // Bytes is the number of bytes received.
+	Err        error
+}
+
+// DownloadError reports the failure of downloading a File.
+type DownloadError struct {
+	SubmissionID types.IntString
+	FileID       types.IntString
+	Path         string
+	Err          error
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("error downloading file %d of submission %d to %s: %v", e.FileID, e.SubmissionID, e.Path, e.Err)
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// Downloader downloads the files of submissions into a directory. Use Client.NewDownloader to create one.
+//
+//	downloader := client.NewDownloader("downloads").Size(inkbunny.SizeFull).Concurrency(4)
+//	results, err := downloader.Download(details.Submissions...)
+//
+// Files are requested with Client.Do, using the http.Client and rate limiters of the Client.
+// They are first written to a ".part" file, which is resumed with a Range request if a download is interrupted,
+// and renamed once the content matches the MD5 hash sent by the API.
+// Files that are already present and match their MD5 hash are skipped. Deleted files are ignored.
+type Downloader struct {
+	client      *Client
+	dir         string
+	size        Size
+	concurrency int
+	onFile      func(DownloadResult)
+	namer       func(SubmissionDetails, File) string
+	sidecars    []Sidecar
+}
+
+// NewDownloader returns a Downloader that saves full size files into dir, one at a time.
+func (c *Client) NewDownloader(dir string) *Downloader {
+	d := &Downloader{client: c.Get(), dir: dir, size: SizeFull, concurrency: 1}
+	d.namer = uniqueNames(d.urlName)
+	return d
+}
+
+// Size sets the size of the files to download. The default is SizeFull.
+func (d *Downloader) Size(size Size) *Downloader {
+	d.size = size
+	return d
+}
+
+// Concurrency sets the number of files downloaded at the same time. The default is 1.
+func (d *Downloader) Concurrency(n int) *Downloader {
+	d.concurrency = max(n, 1)
+	return d
+}
+
+// Template sets the paths of the files relative to the directory. By default, files are saved with the name of their URL,
+// with a suffix like PathTemplate.Namer when files of different submissions have the same name.
+//
+//	downloader.Template(inkbunny.MustParsePathTemplate("{username}/{submission_id}_{page:02}.{ext}"))
+//
+// Files that would be saved to the same path get a suffix, see PathTemplate.Namer.
+func (d *Downloader) Template(template *PathTemplate) *Downloader {
+	d.namer = template.Namer()
+	return d
+}
+
+// Sidecars sets the metadata files written next to each file once it is downloaded or skipped, see WriteSidecars.
+//
+//	downloader.Sidecars(inkbunny.JSONSidecar{}, inkbunny.GalleryDLSidecar{}, inkbunny.XMPSidecar{})
+func (d *Downloader) Sidecars(sidecars ...Sidecar) *Downloader {
+	d.sidecars = sidecars
+	return d
+}
+
+// OnFile sets a function called after each file is downloaded or skipped, e.g. to report progress.
+// It may be called from multiple goroutines at once.
+func (d *Downloader) OnFile(fn func(DownloadResult)) *Downloader {
+	d.onFile = fn
+	return d
+}
+
+// Download downloads every file of the submissions, and returns the results in the order of the files.
+// Files that fail are reported as a *DownloadError, joined with errors.Join, and the other files are still downloaded.
+func (d *Downloader) Download(submissions ...SubmissionDetails) ([]DownloadResult, error) {
+	return d.DownloadContext(d.client.Context(), submissions...)
+}
+
+// DownloadContext is like Downloader.Download but uses ctx for the requests.
+func (d *Downloader) DownloadContext(ctx context.Context, submissions ...SubmissionDetails) ([]DownloadResult, error) {
+	var results []DownloadResult
+	for _, submission := range submissions {
+		for _, file := range submission.Files {
+			if bool(file.Deleted) || d.size.URL(file) == "" {
+				continue
+			}
+			results = append(results, DownloadResult{Submission: submission, File: file})
+		}
+	}
+	// Files are named by ascending ID, so that the file keeping a path without a suffix does not depend on the
+	// order of the submissions.
+	byID := make([]*DownloadResult, len(results))
+	for i := range results {
+		byID[i] = &results[i]
+	}
+	slices.SortStableFunc(byID, func(a, b *DownloadResult) int { return cmp.Compare(a.File.FileID, b.File.FileID) })
+	for _, result := range byID {
+		result.Path = d.path(result.Submission, result.File)
+	}
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, d.concurrency)
+	for i := range results {
+		wg.Add(1)
+		select {
+		case limit <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			wg.Done()
+			continue
+		}
+		go func() {
+			defer func() { <-limit; wg.Done() }()
+			d.download(ctx, &results[i])
+			if results[i].Err == nil {
+				results[i].Err = WriteSidecars(results[i].Path, results[i].Submission, results[i].File, d.sidecars...)
+			}
+			if d.onFile != nil {
+				d.onFile(results[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, &DownloadError{
+				SubmissionID: result.Submission.SubmissionID,
+				FileID:       result.File.FileID,
+				Path:         result.Path,
+				Err:          result.Err,
+			})
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// DownloadPages requests the details of every page of a search, and downloads the files of their submissions.
+// It stops at the first error returned by the Paginator or while requesting the details.
+//
+//	results, err := downloader.DownloadPages(ctx, response.Paginate())
+func (d *Downloader) DownloadPages(ctx context.Context, pages *Paginator) ([]DownloadResult, error) {
+	var results []DownloadResult
+	var errs []error
+	for page, err := range pages.PagesContext(ctx) {
+		if err != nil {
+			return results, errors.Join(append(errs, err)...)
+		}
+		if len(page.Submissions) == 0 {
+			continue
+		}
+		details, err := page.DetailsContext(ctx)
+		if err != nil {
+			return results, errors.Join(append(errs, err)...)
+		}
+		downloaded, err := d.DownloadContext(ctx, details.Submissions...)
+		results = append(results, downloaded...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// download downloads a single file into result.Path.
+func (d *Downloader) download(ctx context.Context, result *DownloadResult) {
+	link := d.size.URL(result.File)
+	expected := strings.ToLower(d.size.MD5(result.File))
+
+	if _, err := os.Stat(result.Path); err == nil {
+		if expected == "" {
+			result.Skipped = true
+			return
+		}
+		sum, err := hashFile(result.Path)
+		if err != nil {
+			result.Err = err
+			return
+		}
+		if sum == expected {
+			result.Skipped = true
+			return
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(result.Path), 0o755); err != nil {
+		result.Err = err
+		return
+	}
+	partial := result.Path + ".part"
+	result.Err = d.fetch(ctx, link, partial, result)
+	if result.Err == nil && expected != "" {
+		result.Err = verify(partial, expected)
+		if result.Err != nil && result.Resumed {
+			// The partial file may not match the current file, so download it again from the start.
+			result.Resumed, result.Bytes = false, 0
+			if result.Err = os.Remove(partial); result.Err == nil {
+				if result.Err = d.fetch(ctx, link, partial, result); result.Err == nil {
+					result.Err = verify(partial, expected)
+				}
+			}
+		}
+		if errors.Is(result.Err, ErrMD5Mismatch) {
+			os.Remove(partial)
+		}
+	}
+	if result.Err == nil {
+		result.Err = os.Rename(partial, result.Path)
+	}
+}
+
+// path returns where a file is saved, from the Template or the name of its URL.
+func (d *Downloader) path(submission SubmissionDetails, f File) string {
+	return filepath.Join(d.dir, filepath.FromSlash(d.namer(submission, f)))
+}
+
+// urlName returns the name of the URL of a file, or its FileName.
+func (d *Downloader) urlName(_ SubmissionDetails, f File) string {
+	name := filepath.Base(f.FileName)
+	if u, err := url.Parse(d.size.URL(f)); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+		name = path.Base(u.Path)
+	}
+	return name
+}
+
+// fetch downloads link into partial, resuming from its current size with a Range request.
+func (d *Downloader) fetch(ctx context.Context, link, partial string, result *DownloadResult) error {
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case response.StatusCode == http.StatusPartialContent && offset > 0:
+		flags = os.O_WRONLY | os.O_APPEND
+		result.Resumed = true
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file is already complete.
+		result.Resumed = true
+		return nil
+	case response.StatusCode != http.StatusOK:
+		return &types.APIError{
+			Code:       types.ErrUnexpectedStatus,
+			Message:    response.Status,
+			Endpoint:   req.URL.Path,
+			StatusCode: response.StatusCode,
+		}
+	}
+
+	out, err := os.OpenFile(partial, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, response.Body)
+	result.Bytes += n
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// verify checks that the MD5 hash of the file at name is expected.
+func verify(name, expected string) error {
+	sum, err := hashFile(name)
+	if err != nil {
+		return err
+	}
+	if sum != expected {
+		return fmt.Errorf("%w: expected %s, got %s", ErrMD5Mismatch, expected, sum)
+	}
+	return nil
+}
+
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
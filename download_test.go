@@ -0,0 +1,85 @@
+package inkbunny_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestDownloadSameNames(t *testing.T) {
+	server, _ := newServer(t, 0)
+	var ids []string
+	contents := make(map[types.IntString]string)
+	for i := range 4 {
+		id := server.AddSubmission(inkbunny.SubmissionDetails{
+			SubmissionBasic: inkbunny.SubmissionBasic{Username: "artist"},
+			Files:           []inkbunny.File{{FileName: "page.png"}},
+		})
+		submission, _ := server.Submission(id)
+		contents[submission.Files[0].FileID] = fmt.Sprintf("content of %d", i)
+		server.SetFileContent(submission.Files[0].FileID, []byte(contents[submission.Files[0].FileID]))
+		ids = append(ids, id.String())
+	}
+	user := login(t, server.Client())
+	details, err := user.SubmissionDetails(inkbunny.SubmissionDetailsRequest{SubmissionIDSlice: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	downloader := user.Client().NewDownloader(dir).Concurrency(4)
+	results, err := downloader.Download(details.Submissions...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make(map[string]types.IntString)
+	for _, result := range results {
+		if other, ok := paths[result.Path]; ok {
+			t.Errorf("files %d and %d were both saved to %s", other, result.File.FileID, result.Path)
+		}
+		paths[result.Path] = result.File.FileID
+		data, err := os.ReadFile(result.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := contents[result.File.FileID]; string(data) != want {
+			t.Errorf("%s: got %q, want %q", result.Path, data, want)
+		}
+	}
+	last := details.Submissions[len(details.Submissions)-1].Files[0].FileID
+	if _, err := os.Stat(filepath.Join(dir, "page_"+last.String()+".png")); err != nil {
+		t.Error(err)
+	}
+
+	again, err := downloader.Download(details.Submissions...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range again {
+		if result.Path != results[i].Path || !result.Skipped {
+			t.Errorf("second download of file %d: got %s, skipped %t, want %s", result.File.FileID, result.Path, result.Skipped, results[i].Path)
+		}
+	}
+
+	backward := slices.Clone(details.Submissions)
+	slices.Reverse(backward)
+	reordered := t.TempDir()
+	reversed, err := user.Client().NewDownloader(reordered).Download(backward...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range reversed {
+		got, _ := filepath.Rel(reordered, result.Path)
+		want, _ := filepath.Rel(dir, results[slices.IndexFunc(results, func(r inkbunny.DownloadResult) bool {
+			return r.File.FileID == result.File.FileID
+		})].Path)
+		if got != want {
+			t.Errorf("file %d in reverse order: got %s, want %s", result.File.FileID, got, want)
+		}
+	}
+}
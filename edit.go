@@ -1,6 +1,7 @@
 package inkbunny
 
 import (
+	"context"
 	"io"
 
 	"github.com/ellypaws/inkbunny/types"
@@ -94,16 +95,29 @@ type EditSubmissionResponse struct {
 // EditSubmission edits an existing submission on Inkbunny based on the provided request parameters.
 // This method requires a valid session ID (SID) and submission ID.
 func (u *User) EditSubmission(req SubmissionEditRequest) (EditSubmissionResponse, error) {
+	return u.EditSubmissionContext(u.Client().Context(), req)
+}
+
+// EditSubmissionContext is like User.EditSubmission but uses ctx for the request.
+func (u *User) EditSubmissionContext(ctx context.Context, req SubmissionEditRequest) (EditSubmissionResponse, error) {
 	if req.SID == "" {
-		req.SID = u.SID
+		req.SID = u.sid()
 	}
 
-	return u.Client().EditSubmission(req)
+	return withSession(ctx, u, req.SID, func(sid string) (EditSubmissionResponse, error) {
+		req.SID = sid
+		return u.Client().EditSubmissionContext(ctx, req)
+	})
 }
 
 // EditSubmission edits an existing submission on Inkbunny based on the provided request parameters.
 // This method requires a valid session ID (SID) and submission ID.
 func (c *Client) EditSubmission(req SubmissionEditRequest) (EditSubmissionResponse, error) {
+	return c.EditSubmissionContext(c.Context(), req)
+}
+
+// EditSubmissionContext is like Client.EditSubmission but uses ctx for the request.
+func (c *Client) EditSubmissionContext(ctx context.Context, req SubmissionEditRequest) (EditSubmissionResponse, error) {
 	if req.SID == "" {
 		return EditSubmissionResponse{}, ErrEmptySID
 	}
@@ -116,7 +130,7 @@ func (c *Client) EditSubmission(req SubmissionEditRequest) (EditSubmissionRespon
 		values.Set("visibility", "yes_nowatch")
 	}
 
-	return PostDecode[EditSubmissionResponse](c, ApiUrl("editsubmission"), values)
+	return PostDecodeContext[EditSubmissionResponse](ctx, c, c.ApiUrl("editsubmission"), values)
 }
 
 // EditSubmission edits an existing submission on Inkbunny based on the provided request parameters.
@@ -124,3 +138,8 @@ func (c *Client) EditSubmission(req SubmissionEditRequest) (EditSubmissionRespon
 func EditSubmission(req SubmissionEditRequest) (EditSubmissionResponse, error) {
 	return DefaultClient.EditSubmission(req)
 }
+
+// EditSubmissionContext is like EditSubmission but uses ctx for the request.
+func EditSubmissionContext(ctx context.Context, req SubmissionEditRequest) (EditSubmissionResponse, error) {
+	return DefaultClient.EditSubmissionContext(ctx, req)
+}
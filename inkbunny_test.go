@@ -0,0 +1,38 @@
+package inkbunny_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/inkbunnytest"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// newServer returns a Server with the user "artist" and n of their submissions, the newest first in ids.
+func newServer(t *testing.T, n int) (*inkbunnytest.Server, []types.IntString) {
+	t.Helper()
+	server := inkbunnytest.NewServer()
+	t.Cleanup(server.Close)
+	server.AddUser("artist", "password")
+	start := time.Now().Add(-time.Duration(n) * time.Minute)
+	ids := make([]types.IntString, n)
+	for i := range n {
+		ids[n-1-i] = server.AddSubmission(inkbunny.SubmissionDetails{SubmissionBasic: inkbunny.SubmissionBasic{
+			Username:         "artist",
+			Title:            fmt.Sprintf("Submission %d", i),
+			CreateDateSystem: types.Time{Time: start.Add(time.Duration(i) * time.Minute)},
+		}})
+	}
+	return server, ids
+}
+
+func login(t *testing.T, client *inkbunny.Client) *inkbunny.User {
+	t.Helper()
+	user, err := client.Login("artist", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
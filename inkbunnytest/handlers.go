@@ -0,0 +1,674 @@
+package inkbunnytest
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// handler handles a parsed API request while holding the Server lock.
+type handler func(r *http.Request) (any, error)
+
+// apiError is an error code with a custom message.
+type apiError struct {
+	code    types.ErrorCode
+	message string
+}
+
+func (e apiError) Error() string {
+	return e.message
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/", s.serveFile)
+	endpoints := map[string]handler{
+		"login":                 s.login,
+		"logout":                s.logout,
+		"userrating":            s.userRating,
+		"search":                s.search,
+		"submissions":           s.submissionDetails,
+		"submissionfavingusers": s.favingUsers,
+		"watchlist":             s.watchlist,
+		"username_autosuggest":  s.usernameAutosuggest,
+		"search_autosuggest":    s.searchAutosuggest,
+		"upload":                s.upload,
+		"editsubmission":        s.editSubmission,
+		"delsubmission":         s.deleteSubmission,
+		"delfile":               s.deleteFile,
+		"reorderfile":           s.reorderFile,
+	}
+	for name, h := range endpoints {
+		mux.HandleFunc("/api_"+name+".php", s.endpoint(name, h))
+	}
+	return mux
+}
+
+// endpoint parses the form, applies failures set with FailNext and writes the result of h as JSON.
+func (s *Server) endpoint(name string, h handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(64 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.calls[name]++
+		var result any
+		var err error
+		if failures := s.failures[name]; len(failures) > 0 {
+			s.failures[name] = failures[1:]
+			err = failures[0]
+		} else {
+			result, err = h(r)
+		}
+		s.mu.Unlock()
+
+		if errors.Is(err, types.ErrUnexpectedStatus) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			result = errorResponse(err)
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	}
+}
+
+func errorResponse(err error) map[string]any {
+	var custom apiError
+	if errors.As(err, &custom) {
+		return map[string]any{"error_code": int(custom.code), "error_message": custom.message}
+	}
+	var code types.ErrorCode
+	if errors.As(err, &code) {
+		message, ok := errorMessages[code]
+		if !ok {
+			message = code.Error()
+		}
+		return map[string]any{"error_code": int(code), "error_message": message}
+	}
+	return map[string]any{"error_code": int(types.ErrDatabaseError), "error_message": err.Error()}
+}
+
+var errorMessages = map[types.ErrorCode]string{
+	types.ErrInvalidLogin:                    "Invalid login. Username and password incorrect or account does not have API Access enabled in account Settings.",
+	types.ErrEmptySessionID:                  "No Session ID sent as variable 'sid'.",
+	types.ErrInvalidSessionID:                "Invalid Session ID sent as variable 'sid'.",
+	types.ErrInvalidResultsID:                "Invalid Results ID sent as variable 'rid'. It contains invalid characters.",
+	types.ErrNoResultsFound:                  "No results found for Results ID sent as variable 'rid'.",
+	types.ErrNoPermissionToUpload:            "Current user does not have permission to upload files.",
+	types.ErrDatabaseError:                   "Database error. Unable to create a new submission.",
+	types.ErrNoValidSubmissionID:             "No valid submission id given.",
+	types.ErrNoPermissionToEditSubmission:    "Current user does not have permission to edit this submission.",
+	types.ErrInvalidFileName:                 "Incoming file names cannot contain a double-dot '..'. Please rename your file and try again.",
+	types.ErrCouldNotExtractFiles:            "Could not extract any files from that ZIP.",
+	types.ErrNotZIPFile:                      "The file you uploaded was not a ZIP file.",
+	types.ErrFileCouldNotBeRead:              "File could not be read.",
+	types.ErrNoPermissionToDeleteSubmission:  "Current user does not have permission to delete this submission.",
+	types.ErrNoPermissionToRemoveFile:        "Current user does not have permission to remove this file.",
+	types.ErrNoPermissionToChangeOrderOfFile: "Current user does not have permission to change the order of this file.",
+	types.ErrTooManySubmissionIDsToQuery:     "Too many submission ids to query. Limit exceeded.",
+}
+
+// session returns the session of the request, or types.ErrEmptySessionID and types.ErrInvalidSessionID.
+func (s *Server) session(r *http.Request) (*session, error) {
+	sid := r.FormValue("sid")
+	if sid == "" {
+		return nil, types.ErrEmptySessionID
+	}
+	sess, ok := s.sessions[sid]
+	if !ok {
+		return nil, types.ErrInvalidSessionID
+	}
+	now := s.Now()
+	if s.SessionTTL > 0 && now.Sub(sess.lastUsed) > s.SessionTTL {
+		delete(s.sessions, sid)
+		return nil, types.ErrInvalidSessionID
+	}
+	sess.lastUsed = now
+	return sess, nil
+}
+
+// owned returns the submission with the ID in key if it is owned by the session account, or denied otherwise.
+func (s *Server) owned(r *http.Request, key string, denied types.ErrorCode) (*session, *entry, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := strconv.Atoi(r.FormValue(key))
+	if err != nil {
+		return nil, nil, types.ErrNoValidSubmissionID
+	}
+	e, ok := s.submissions[types.IntString(id)]
+	if !ok {
+		return nil, nil, types.ErrNoValidSubmissionID
+	}
+	if sess.account == nil || sess.account.UserID != e.UserID {
+		return nil, nil, denied
+	}
+	return sess, e, nil
+}
+
+// file returns the submission and index of the file with the ID in file_id if it is owned by the session account.
+func (s *Server) file(r *http.Request, denied types.ErrorCode) (*entry, int, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	id, err := strconv.Atoi(r.FormValue("file_id"))
+	if err != nil {
+		return nil, 0, denied
+	}
+	for _, e := range s.submissions {
+		for i, f := range e.Files {
+			if f.FileID != types.IntString(id) || f.Deleted {
+				continue
+			}
+			if sess.account == nil || sess.account.UserID != e.UserID {
+				return nil, 0, denied
+			}
+			return e, i, nil
+		}
+	}
+	return nil, 0, denied
+}
+
+func (s *Server) login(r *http.Request) (any, error) {
+	username, password := r.FormValue("username"), r.FormValue("password")
+	var a *account
+	if !strings.EqualFold(username, "guest") {
+		a = s.accounts[strings.ToLower(username)]
+		if a == nil || a.Password == "" || a.Password != password {
+			return nil, types.ErrInvalidLogin
+		}
+	}
+	sess := s.newSession(a)
+	var userID types.IntString
+	if a != nil {
+		userID = a.UserID
+	}
+	return map[string]any{"sid": sess.sid, "user_id": userID, "ratingsmask": sess.ratings.String()}, nil
+}
+
+func (s *Server) logout(r *http.Request) (any, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.sessions, sess.sid)
+	return types.LogoutResponse{SID: sess.sid, Logout: "success"}, nil
+}
+
+func (s *Server) userRating(r *http.Request) (any, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, err
+	}
+	var mask strings.Builder
+	for i := 1; i <= 5; i++ {
+		if yes(r.FormValue("tag[" + strconv.Itoa(i) + "]")) {
+			mask.WriteByte('1')
+		} else {
+			mask.WriteByte('0')
+		}
+	}
+	sess.ratings = types.ParseMask(mask.String())
+	return map[string]any{"sid": sess.sid, "ratingsmask": sess.ratings.String()}, nil
+}
+
+func (s *Server) submissionDetails(r *http.Request) (any, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, err
+	}
+	ids := splitList(r.FormValue("submission_ids"))
+	if len(ids) == 0 {
+		return nil, types.ErrNoValidSubmissionID
+	}
+	if len(ids) > s.MaxSubmissionIDs {
+		return nil, types.ErrTooManySubmissionIDsToQuery
+	}
+	response := inkbunny.SubmissionDetailsResponse{SID: sess.sid, Submissions: []inkbunny.SubmissionDetails{}}
+	for _, raw := range ids {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		e, ok := s.submissions[types.IntString(id)]
+		if !ok || !s.visible(sess, e) {
+			continue
+		}
+		details := e.SubmissionDetails
+		details.Files = slices.DeleteFunc(slices.Clone(details.Files), func(f inkbunny.File) bool { return bool(f.Deleted) })
+		if sess.account != nil {
+			_, details.Favorite = sess.account.favorites[e.SubmissionID]
+		}
+		if !yes(r.FormValue("show_description")) {
+			details.Description = ""
+		}
+		if !yes(r.FormValue("show_description_bbcode_parsed")) {
+			details.DescriptionBBCodeParsed = ""
+		}
+		if !yes(r.FormValue("show_writing")) {
+			details.Writing = ""
+		}
+		if !yes(r.FormValue("show_writing_bbcode_parsed")) {
+			details.WritingBBCodeParsed = ""
+		}
+		if !yes(r.FormValue("show_pools")) {
+			details.Pools = nil
+		}
+		response.Submissions = append(response.Submissions, details)
+	}
+	response.ResultsCount = types.IntString(len(response.Submissions))
+	return response, nil
+}
+
+// visible reports whether the session can see a submission.
+func (s *Server) visible(sess *session, e *entry) bool {
+	if e.Deleted {
+		return false
+	}
+	owner := sess.account != nil && sess.account.UserID == e.UserID
+	if !bool(e.Public) && !owner {
+		return false
+	}
+	if e.GuestBlock && sess.account == nil {
+		return false
+	}
+	return owner || sess.ratings.Allows(e)
+}
+
+func (s *Server) favingUsers(r *http.Request) (any, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(r.FormValue("submission_id"))
+	if err != nil {
+		return nil, types.ErrNoValidSubmissionID
+	}
+	if e, ok := s.submissions[types.IntString(id)]; !ok || !s.visible(sess, e) {
+		return nil, types.ErrNoValidSubmissionID
+	}
+	response := inkbunny.SubmissionFavoritesResponse{Sid: sess.sid, Users: []types.UsernameID{}}
+	for _, a := range s.sortedAccounts() {
+		if _, ok := a.favorites[types.IntString(id)]; ok {
+			response.Users = append(response.Users, types.UsernameID{UserID: a.UserID.String(), Username: a.Username})
+		}
+	}
+	return response, nil
+}
+
+func (s *Server) watchlist(r *http.Request) (any, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, err
+	}
+	watches := []types.UsernameID{}
+	if sess.account != nil {
+		for _, id := range sess.account.watching {
+			for _, a := range s.accounts {
+				if a.UserID == id {
+					watches = append(watches, types.UsernameID{UserID: id.String(), Username: a.Username})
+				}
+			}
+		}
+	}
+	return map[string]any{"watches": watches}, nil
+}
+
+func (s *Server) usernameAutosuggest(r *http.Request) (any, error) {
+	term := r.FormValue("username")
+	results := []types.Autocomplete{}
+	if term == "" {
+		return map[string]any{"results": results}, nil
+	}
+	for _, a := range s.sortedAccounts() {
+		if strings.HasPrefix(strings.ToLower(a.Username), strings.ToLower(term)) {
+			results = append(results, types.Autocomplete{
+				ID:         a.UserID,
+				Value:      a.Username,
+				SingleWord: a.Username,
+				SearchTerm: term,
+			})
+		}
+	}
+	return map[string]any{"results": results}, nil
+}
+
+func (s *Server) searchAutosuggest(r *http.Request) (any, error) {
+	input := strings.TrimSpace(r.FormValue("keyword"))
+	results := []inkbunny.KeywordAutocomplete{}
+	if input == "" {
+		return map[string]any{"results": results}, nil
+	}
+	ratings := types.ParseMask(r.FormValue("ratingsmask"))
+	if r.FormValue("ratingsmask") == "" {
+		ratings = types.ParseMask("1")
+	}
+
+	var terms []string
+	if yes(r.FormValue("underscorespaces")) {
+		terms = []string{strings.ReplaceAll(input, "_", " ")}
+	} else {
+		words := strings.Fields(input)
+		for i := len(words) - 1; i >= 0; i-- {
+			terms = append(terms, strings.Join(words[i:], " "))
+		}
+	}
+
+	type suggestion struct {
+		keyword inkbunny.Keyword
+		term    string
+		count   int
+	}
+	seen := make(map[string]*suggestion)
+	var suggestions []*suggestion
+	for _, e := range s.submissions {
+		if bool(e.Deleted) || !bool(e.Public) || !ratings.Allows(e) {
+			continue
+		}
+		for _, k := range e.Keywords {
+			name := strings.ToLower(k.KeywordName)
+			for _, term := range terms {
+				if !strings.HasPrefix(name, strings.ToLower(term)) {
+					continue
+				}
+				if sug, ok := seen[name]; ok {
+					sug.count++
+				} else {
+					seen[name] = &suggestion{keyword: k, term: term, count: 1}
+					suggestions = append(suggestions, seen[name])
+				}
+				break
+			}
+		}
+	}
+	slices.SortFunc(suggestions, func(a, b *suggestion) int {
+		if a.count != b.count {
+			return b.count - a.count
+		}
+		return strings.Compare(a.keyword.KeywordName, b.keyword.KeywordName)
+	})
+	for _, sug := range suggestions {
+		results = append(results, inkbunny.KeywordAutocomplete{
+			ID:               sug.keyword.KeywordID,
+			Value:            strings.TrimSpace(strings.TrimSuffix(input, sug.term) + sug.keyword.KeywordName),
+			Keyword:          sug.keyword.KeywordName,
+			SearchTerm:       sug.term,
+			SubmissionsCount: types.IntString(sug.count),
+		})
+	}
+	return map[string]any{"results": results}, nil
+}
+
+var uploadedFile = regexp.MustCompile(`^uploaded(file|thumbnail)\[(\d+)]$`)
+
+func (s *Server) upload(r *http.Request) (any, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, err
+	}
+	if sess.account == nil || !sess.account.CanUpload {
+		return nil, types.ErrNoPermissionToUpload
+	}
+	type upload struct {
+		name    string
+		content []byte
+	}
+	var uploads []upload
+	if r.MultipartForm != nil {
+		var indexes []int
+		for key := range r.MultipartForm.File {
+			if m := uploadedFile.FindStringSubmatch(key); m != nil && m[1] == "file" {
+				i, _ := strconv.Atoi(m[2])
+				indexes = append(indexes, i)
+			}
+		}
+		slices.Sort(indexes)
+		for _, i := range indexes {
+			header := r.MultipartForm.File["uploadedfile["+strconv.Itoa(i)+"]"][0]
+			content, err := readFile(header)
+			if err != nil {
+				return nil, err
+			}
+			uploads = append(uploads, upload{name: header.Filename, content: content})
+		}
+		if headers := r.MultipartForm.File["zipfile"]; len(headers) > 0 {
+			content, err := readFile(headers[0])
+			if err != nil {
+				return nil, err
+			}
+			archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+			if err != nil {
+				return nil, types.ErrNotZIPFile
+			}
+			for _, f := range archive.File {
+				if f.FileInfo().IsDir() || strings.Contains(f.Name, "/") {
+					return nil, types.ErrCouldNotExtractFiles
+				}
+				rc, err := f.Open()
+				if err != nil {
+					return nil, types.ErrCouldNotExtractFiles
+				}
+				content, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					return nil, types.ErrCouldNotExtractFiles
+				}
+				uploads = append(uploads, upload{name: f.Name, content: content})
+			}
+			if len(archive.File) == 0 {
+				return nil, types.ErrCouldNotExtractFiles
+			}
+		}
+	}
+	if len(uploads) == 0 {
+		return nil, types.ErrFileCouldNotBeRead
+	}
+	for _, u := range uploads {
+		if strings.Contains(u.name, "..") {
+			return nil, types.ErrInvalidFileName
+		}
+	}
+
+	var e *entry
+	if id := r.FormValue("submission_id"); id != "" {
+		_, e, err = s.owned(r, "submission_id", types.ErrNoPermissionToEditSubmission)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		id := s.addSubmission(inkbunny.SubmissionDetails{
+			SubmissionBasic: inkbunny.SubmissionBasic{Username: sess.account.Username},
+		}, false)
+		e = s.submissions[id]
+	}
+
+	replace, _ := strconv.Atoi(r.FormValue("replace"))
+	for _, u := range uploads {
+		if replace != 0 {
+			for i := range e.Files {
+				if e.Files[i].FileID == types.IntString(replace) {
+					e.Files[i].FileName = u.name
+					s.hashFile(&e.Files[i], u.content)
+				}
+			}
+			replace = 0
+			continue
+		}
+		f := inkbunny.File{FileName: u.name, MimeType: http.DetectContentType(u.content)}
+		s.initFile(e, &f, len(e.Files))
+		s.hashFile(&f, u.content)
+		e.Files = append(e.Files, f)
+	}
+	s.refresh(e)
+	return inkbunny.UploadResponse{SID: sess.sid, SubmissionID: e.SubmissionID.String()}, nil
+}
+
+func readFile(header *multipart.FileHeader) ([]byte, error) {
+	f, err := header.Open()
+	if err != nil {
+		return nil, types.ErrFileCouldNotBeRead
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, types.ErrFileCouldNotBeRead
+	}
+	return content, nil
+}
+
+func (s *Server) editSubmission(r *http.Request) (any, error) {
+	_, e, err := s.owned(r, "submission_id", types.ErrNoPermissionToEditSubmission)
+	if err != nil {
+		return nil, err
+	}
+	if has(r, "title") {
+		e.Title = r.FormValue("title")
+	}
+	if has(r, "desc") {
+		e.Description = r.FormValue("desc")
+		e.DescriptionBBCodeParsed = r.FormValue("desc")
+	}
+	if has(r, "story") {
+		e.Writing = r.FormValue("story")
+		e.WritingBBCodeParsed = r.FormValue("story")
+	}
+	if has(r, "keywords") {
+		e.Keywords = nil
+		for _, name := range splitList(strings.ReplaceAll(r.FormValue("keywords"), " ", ",")) {
+			e.Keywords = append(e.Keywords, inkbunny.Keyword{KeywordID: s.keywordID(name), KeywordName: name})
+		}
+	}
+	if has(r, "submission_type") {
+		if t, err := strconv.Atoi(r.FormValue("submission_type")); err == nil && t > 0 {
+			e.SubmissionTypeID = types.IntString(t)
+			e.TypeName = typeNames[inkbunny.SubmissionType(t)]
+		}
+	}
+	if has(r, "visibility") {
+		e.Public = types.BooleanYN(strings.HasPrefix(r.FormValue("visibility"), "yes"))
+	}
+	if has(r, "scraps") {
+		e.Scraps = types.BooleanYN(yes(r.FormValue("scraps")))
+	}
+	if has(r, "guest_block") {
+		e.GuestBlock = types.BooleanYN(yes(r.FormValue("guest_block")))
+	}
+	if has(r, "friends_only") {
+		e.FriendsOnly = types.BooleanYN(yes(r.FormValue("friends_only")))
+	}
+	for tag := types.IntString(2); tag <= 5; tag++ {
+		key := "tag[" + tag.String() + "]"
+		if !has(r, key) {
+			continue
+		}
+		e.Ratings = slices.DeleteFunc(e.Ratings, func(rating inkbunny.SubmissionRating) bool { return rating.ContentTagID == tag })
+		if yes(r.FormValue(key)) {
+			e.Ratings = append(e.Ratings, inkbunny.SubmissionRating{ContentTagID: tag, Name: tagNames[tag]})
+		}
+	}
+	s.refresh(e)
+	return inkbunny.EditSubmissionResponse{SubmissionID: e.SubmissionID}, nil
+}
+
+var tagNames = map[types.IntString]string{
+	2: "Nudity - Nonsexual nudity exposing breasts or genitals (must not show arousal)",
+	3: "Violence - Mild violence",
+	4: "Sexual Themes - Erotic imagery, sexual activity or arousal",
+	5: "Strong Violence - Strong violence, blood, serious injury or death",
+}
+
+func (s *Server) deleteSubmission(r *http.Request) (any, error) {
+	_, e, err := s.owned(r, "submission_id", types.ErrNoPermissionToDeleteSubmission)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.submissions, e.SubmissionID)
+	return inkbunny.DeleteSubmissionResponse{SubmissionID: e.SubmissionID.String()}, nil
+}
+
+func (s *Server) deleteFile(r *http.Request) (any, error) {
+	e, i, err := s.file(r, types.ErrNoPermissionToRemoveFile)
+	if err != nil {
+		return nil, err
+	}
+	e.Files[i].Deleted = true
+	response := inkbunny.DeleteFileResponse{SubmissionID: e.SubmissionID, FileID: e.Files[i].FileID}
+	s.refresh(e)
+	return response, nil
+}
+
+func (s *Server) reorderFile(r *http.Request) (any, error) {
+	e, i, err := s.file(r, types.ErrNoPermissionToChangeOrderOfFile)
+	if err != nil {
+		return nil, err
+	}
+	position, err := strconv.Atoi(r.FormValue("newpos"))
+	if err != nil || position < 0 {
+		return nil, types.ErrNoPermissionToChangeOrderOfFile
+	}
+	file := e.Files[i]
+	files := slices.Delete(slices.Clone(e.Files), i, i+1)
+	position = min(position, len(files))
+	e.Files = slices.Insert(files, position, file)
+	for j := range e.Files {
+		e.Files[j].SubmissionFileOrder = types.IntString(j)
+	}
+	s.refresh(e)
+	return inkbunny.ReorderFileResponse{SubmissionID: e.SubmissionID, FileID: file.FileID, NewPosition: types.IntString(position)}, nil
+}
+
+// sortedAccounts returns the accounts ordered by username.
+func (s *Server) sortedAccounts() []*account {
+	accounts := make([]*account, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		accounts = append(accounts, a)
+	}
+	slices.SortFunc(accounts, func(a, b *account) int {
+		return strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username))
+	})
+	return accounts
+}
+
+func has(r *http.Request, key string) bool {
+	_, ok := r.Form[key]
+	if !ok && r.MultipartForm != nil {
+		_, ok = r.MultipartForm.Value[key]
+	}
+	return ok
+}
+
+func yes(value string) bool {
+	switch strings.ToLower(value) {
+	case "yes", "true", "t", "1":
+		return true
+	default:
+		return false
+	}
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,450 @@
+package inkbunnytest
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode sets how a Recorder handles requests.
+type Mode int
+
+const (
+	// ModeReplay only replays recorded interactions, each of them once. Unmatched requests fail with ErrNoInteraction.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request and records it, replacing the existing cassette.
+	ModeRecord
+	// ModeReplayOrRecord replays recorded interactions and records requests that have no match.
+	ModeReplayOrRecord
+)
+
+// ErrNoInteraction is returned by Recorder.RoundTrip in ModeReplay when no recorded interaction matches a request,
+// or when every interaction that matches was already replayed. The error names the method, endpoint and form of the request.
+var ErrNoInteraction = errors.New("no recorded interaction matches request")
+
+// Cassette is a set of recorded interactions, saved as JSON.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request normalized for matching.
+// The Form contains the query and body values, with uploaded files recorded as "name:md5".
+type RecordedRequest struct {
+	Method   string     `json:"method"`
+	Endpoint string     `json:"endpoint"`
+	Form     url.Values `json:"form,omitempty"`
+}
+
+// RecordedResponse is a recorded response. Bodies that are not valid UTF-8 are stored as base64.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+	Base64     bool        `json:"base64,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records Inkbunny API exchanges into a Cassette and replays them offline.
+// Install it with inkbunny.WithClient:
+//
+//	recorder, err := inkbunnytest.NewRecorder("testdata/search.json", inkbunnytest.ModeReplayOrRecord)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer recorder.Save()
+//	client := inkbunny.NewClient(inkbunny.WithClient(recorder.Client()))
+//
+// Requests match on their method, endpoint path and form values, regardless of the order of the fields.
+// The sid and password fields and the username sent to api_login.php are ignored when matching.
+//
+// Recorded session IDs, passwords and usernames that logged in are replaced by placeholders,
+// as is every string in Recorder.Scrub. Only form values and JSON strings that are equal to a secret are replaced,
+// so that keys and other values are kept. A replayed login returns the placeholders instead of the real values.
+// When replaying, the username and password sent to a recorded login are mapped back to their placeholders,
+// and placeholders that are still unknown match any value, so requests that contain them still match.
+type Recorder struct {
+	// Transport sends the requests that are recorded. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// Scrub lists additional strings to redact from recorded requests and responses.
+	Scrub []string
+
+	mode     Mode
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	secrets  map[string]string
+	changed  bool
+}
+
+// NewRecorder returns a Recorder for the cassette at path.
+// The cassette is loaded unless mode is ModeRecord. A missing cassette is only an error in ModeReplay.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, secrets: make(map[string]string)}
+	if mode == ModeRecord {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && mode == ModeReplayOrRecord {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("error decoding cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client returns an http.Client that uses the Recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Cassette returns a copy of the recorded interactions.
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Cassette{Interactions: slices.Clone(r.cassette.Interactions)}
+}
+
+// Save writes the cassette to its path if anything was recorded.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return err
+	}
+	r.changed = false
+	return nil
+}
+
+// RoundTrip replays a recorded response for req, or sends and records it depending on the Mode.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := normalize(req, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode != ModeRecord {
+		r.mu.Lock()
+		interaction, ok := r.match(recorded)
+		if ok {
+			r.relearn(recorded, interaction.Request)
+		}
+		unmatched := r.scrubRequest(recorded)
+		r.mu.Unlock()
+		if ok {
+			return interaction.Response.response(req)
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s %s", ErrNoInteraction, unmatched.Method, unmatched.Endpoint, matchForm(unmatched).Encode())
+		}
+	}
+
+	send := req.Clone(req.Context())
+	send.Body = io.NopCloser(bytes.NewReader(body))
+	send.ContentLength = int64(len(body))
+	response, err := cmp.Or[http.RoundTripper](r.Transport, http.DefaultTransport).RoundTrip(send)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(content))
+	response.ContentLength = int64(len(content))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.learn(recorded, content)
+	interaction := Interaction{
+		Request: r.scrubRequest(recorded),
+		Response: RecordedResponse{
+			StatusCode: response.StatusCode,
+			Header:     http.Header{},
+		},
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "" {
+		interaction.Response.Header.Set("Content-Type", contentType)
+	}
+	if utf8.Valid(content) {
+		interaction.Response.Body = r.scrubJSON(string(content))
+	} else {
+		interaction.Response.Body = base64.StdEncoding.EncodeToString(content)
+		interaction.Response.Base64 = true
+	}
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.used = append(r.used, true)
+	r.changed = true
+	return response, nil
+}
+
+// match returns the first unused interaction matching req. Each interaction is replayed once.
+func (r *Recorder) match(req RecordedRequest) (Interaction, bool) {
+	req = r.scrubRequest(req)
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] && r.matches(interaction.Request, req) {
+			r.used[i] = true
+			return interaction, true
+		}
+	}
+	return Interaction{}, false
+}
+
+// ignored are the fields that differ between recording and replaying, and are not used for matching.
+var ignored = []string{"sid", "password"}
+
+// matches reports whether a scrubbed request matches a recorded one.
+// Recorded placeholders that were not learned while replaying match any value.
+func (r *Recorder) matches(recorded, req RecordedRequest) bool {
+	if recorded.Method != req.Method || recorded.Endpoint != req.Endpoint {
+		return false
+	}
+	a, b := matchForm(recorded), matchForm(req)
+	if len(a) != len(b) {
+		return false
+	}
+	for key, values := range a {
+		other, ok := b[key]
+		if !ok || len(values) != len(other) {
+			return false
+		}
+		for i, value := range values {
+			if value != other[i] && !r.unknownPlaceholder(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchForm returns the form values of req that are used for matching, sorted.
+func matchForm(req RecordedRequest) url.Values {
+	form := url.Values{}
+	for key, values := range req.Form {
+		if slices.Contains(ignored, key) || (key == "username" && path.Base(req.Endpoint) == "api_login.php") {
+			continue
+		}
+		form[key] = slices.Sorted(slices.Values(values))
+	}
+	return form
+}
+
+// placeholder matches the placeholders of scrubbed secrets.
+var placeholder = regexp.MustCompile(`^redacted-(?:(?:user|password|sid)-)?\d+$`)
+
+func (r *Recorder) unknownPlaceholder(value string) bool {
+	if !placeholder.MatchString(value) {
+		return false
+	}
+	for _, known := range r.secrets {
+		if known == value {
+			return false
+		}
+	}
+	return true
+}
+
+// relearn maps the username and password sent to a replayed login back to the placeholders of the recording,
+// so that later requests containing them are scrubbed the same way as when they were recorded.
+func (r *Recorder) relearn(req, recorded RecordedRequest) {
+	if path.Base(req.Endpoint) != "api_login.php" {
+		return
+	}
+	for _, key := range []string{"username", "password"} {
+		value, replaced := req.Form.Get(key), recorded.Form.Get(key)
+		if value != "" && value != replaced && placeholder.MatchString(replaced) {
+			r.secrets[value] = replaced
+		}
+	}
+}
+
+// learn registers the secrets sent to or returned by the API, so that they are scrubbed.
+func (r *Recorder) learn(req RecordedRequest, response []byte) {
+	if path.Base(req.Endpoint) == "api_login.php" {
+		if username := req.Form.Get("username"); username != "" && !strings.EqualFold(username, "guest") {
+			r.secret(username, "redacted-user-%d")
+		}
+		if password := req.Form.Get("password"); password != "" {
+			r.secret(password, "redacted-password-%d")
+		}
+	}
+	if sid := req.Form.Get("sid"); sid != "" {
+		r.secret(sid, "redacted-sid-%d")
+	}
+	var body struct {
+		SID string `json:"sid"`
+	}
+	if json.Unmarshal(response, &body) == nil && body.SID != "" {
+		r.secret(body.SID, "redacted-sid-%d")
+	}
+	for _, s := range r.Scrub {
+		r.secret(s, "redacted-%d")
+	}
+}
+
+func (r *Recorder) secret(value, placeholder string) {
+	if _, ok := r.secrets[value]; !ok {
+		r.secrets[value] = fmt.Sprintf(placeholder, len(r.secrets)+1)
+	}
+}
+
+// scrub returns the placeholder of s if it is a secret.
+func (r *Recorder) scrub(s string) string {
+	if replaced, ok := r.secrets[s]; ok {
+		return replaced
+	}
+	return s
+}
+
+// jsonString matches a JSON string, followed by a colon when it is a key.
+var jsonString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"(\s*:)?`)
+
+// scrubJSON replaces the JSON string values of body that are equal to a secret, keeping keys and formatting.
+func (r *Recorder) scrubJSON(body string) string {
+	return jsonString.ReplaceAllStringFunc(body, func(match string) string {
+		if strings.HasSuffix(match, ":") {
+			return match
+		}
+		var value string
+		if json.Unmarshal([]byte(match), &value) != nil {
+			return match
+		}
+		replaced, ok := r.secrets[value]
+		if !ok {
+			return match
+		}
+		quoted, _ := json.Marshal(replaced)
+		return string(quoted)
+	})
+}
+
+func (r *Recorder) scrubRequest(req RecordedRequest) RecordedRequest {
+	form := make(url.Values, len(req.Form))
+	for key, values := range req.Form {
+		for _, v := range values {
+			form[key] = append(form[key], r.scrub(v))
+		}
+	}
+	req.Form = form
+	return req
+}
+
+func (response RecordedResponse) response(req *http.Request) (*http.Response, error) {
+	body := []byte(response.Body)
+	if response.Base64 {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(response.Body); err != nil {
+			return nil, fmt.Errorf("error decoding recorded body: %w", err)
+		}
+	}
+	header := response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// readBody reads and closes the body of req.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+// normalize merges the query and url encoded or multipart body of a request into a RecordedRequest.
+func normalize(req *http.Request, body []byte) (RecordedRequest, error) {
+	form := url.Values{}
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return RecordedRequest{}, err
+		}
+		form = values
+	case strings.HasPrefix(mediaType, "multipart/"):
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return RecordedRequest{}, err
+			}
+			content, err := io.ReadAll(part)
+			if err != nil {
+				return RecordedRequest{}, err
+			}
+			value := string(content)
+			if part.FileName() != "" {
+				sum := md5.Sum(content)
+				value = part.FileName() + ":" + hex.EncodeToString(sum[:])
+			}
+			form.Add(part.FormName(), value)
+		}
+	}
+	for key, values := range req.URL.Query() {
+		if _, ok := form[key]; !ok {
+			form[key] = values
+		}
+	}
+	return RecordedRequest{Method: req.Method, Endpoint: req.URL.Path, Form: form}, nil
+}
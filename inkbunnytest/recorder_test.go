@@ -0,0 +1,137 @@
+package inkbunnytest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/inkbunnytest"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// record logs in as a user with a short username and password, and searches their submissions.
+func record(t *testing.T, path string) int {
+	t.Helper()
+	server := inkbunnytest.NewServer()
+	defer server.Close()
+	server.AddUser("user", "id")
+	server.AddSubmission(inkbunny.SubmissionDetails{SubmissionBasic: inkbunny.SubmissionBasic{Username: "user", Title: "yes"}})
+
+	recorder, err := inkbunnytest.NewRecorder(path, inkbunnytest.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Transport = server.Server.Client().Transport
+	base, _ := url.Parse(server.URL)
+	client := inkbunny.NewClient(inkbunny.WithBaseURL(base), inkbunny.WithClient(recorder.Client()))
+
+	user, err := client.Login("user", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: user.Username})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return len(response.Submissions)
+}
+
+func TestRecorderScrubsWholeValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	record(t, path)
+
+	recorder, err := inkbunnytest.NewRecorder(path, inkbunnytest.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, interaction := range recorder.Cassette().Interactions {
+		body := interaction.Response.Body
+		if strings.Contains(body, "redacted-user-1_id") {
+			t.Errorf("key was scrubbed: %s", body)
+		}
+		if strings.Contains(body, `"user"`) || strings.Contains(body, `"id"`) {
+			t.Errorf("secret was not scrubbed: %s", body)
+		}
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(body), &decoded); err != nil {
+			t.Errorf("scrubbed body does not decode: %v", err)
+		}
+	}
+
+	var login inkbunny.User
+	if err := json.Unmarshal([]byte(recorder.Cassette().Interactions[0].Response.Body), &login); err != nil {
+		t.Fatal(err)
+	}
+	if login.UserID == 0 {
+		t.Errorf("user_id was not kept: %+v", login)
+	}
+	var search inkbunny.SubmissionSearchResponse
+	if err := json.Unmarshal([]byte(recorder.Cassette().Interactions[1].Response.Body), &search); err != nil {
+		t.Fatal(err)
+	}
+	if len(search.Submissions) != 1 || search.Submissions[0].Title != "yes" {
+		t.Errorf("unrelated values were scrubbed: %+v", search.Submissions)
+	}
+}
+
+func TestRecorderReplaysScrubbedUsername(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	want := record(t, path)
+
+	recorder, err := inkbunnytest.NewRecorder(path, inkbunnytest.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := inkbunny.NewClient(inkbunny.WithBaseURL(&url.URL{Scheme: "http", Host: "127.0.0.1:1"}), inkbunny.WithClient(recorder.Client()))
+	user, err := client.Login("user", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: user.Username})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Submissions) != want {
+		t.Errorf("got %d submissions, want %d", len(response.Submissions), want)
+	}
+
+	_, err = user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "someone", Text: "fox", Random: types.Yes})
+	if !errors.Is(err, inkbunnytest.ErrNoInteraction) {
+		t.Errorf("unrecorded request: got %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestRecorderReplaysOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	record(t, path)
+
+	recorder, err := inkbunnytest.NewRecorder(path, inkbunnytest.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := inkbunny.NewClient(inkbunny.WithBaseURL(&url.URL{Scheme: "http", Host: "127.0.0.1:1"}), inkbunny.WithClient(recorder.Client()))
+	user, err := client.Login("user", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := inkbunny.SubmissionSearchRequest{Username: user.Username}
+	if _, err := user.SearchSubmissions(request); err != nil {
+		t.Fatal(err)
+	}
+	_, err = user.SearchSubmissions(request)
+	if !errors.Is(err, inkbunnytest.ErrNoInteraction) {
+		t.Fatalf("replayed search: got %v, want ErrNoInteraction", err)
+	}
+	for _, want := range []string{"POST", "/api_search.php", "username="} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got %q, want it to name the request with %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package inkbunnytest
+
+import (
+	"cmp"
+	"crypto/md5"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+var ridPattern = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// search implements both modes of api_search.php.
+// Mode 1 runs the search and optionally stores it as a results set, Mode 2 pages through a stored results set.
+func (s *Server) search(r *http.Request) (any, error) {
+	sess, err := s.session(r)
+	if err != nil {
+		return nil, err
+	}
+	now := s.Now()
+
+	var set *resultSet
+	if rid := r.FormValue("rid"); rid != "" {
+		if !ridPattern.MatchString(rid) {
+			return nil, types.ErrInvalidResultsID
+		}
+		set = s.results[rid]
+		if set == nil {
+			return nil, types.ErrNoResultsFound
+		}
+		if now.Sub(set.lastAccess) > s.RIDTTL {
+			delete(s.results, rid)
+			return nil, types.ErrNoResultsFound
+		}
+	} else {
+		set = s.find(r, sess)
+		if yes(r.FormValue("get_rid")) {
+			set.rid = fmt.Sprintf("%x", md5.Sum(fmt.Appendf(nil, "rid-%d-%d", s.nextID(), now.UnixNano())))
+			s.results[set.rid] = set
+		}
+	}
+	set.lastAccess = now
+
+	if perPage, err := strconv.Atoi(r.FormValue("submissions_per_page")); err == nil {
+		set.perPage = min(max(perPage, 0), 100)
+	}
+	page, err := strconv.Atoi(r.FormValue("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pages := 0
+	var ids []types.IntString
+	if set.perPage > 0 {
+		pages = (len(set.ids) + set.perPage - 1) / set.perPage
+		start := min((page-1)*set.perPage, len(set.ids))
+		ids = set.ids[start:min(start+set.perPage, len(set.ids))]
+	}
+
+	response := inkbunny.SubmissionSearchResponse{
+		SID:                  sess.sid,
+		ResultsCountAll:      types.IntString(len(set.ids)),
+		ResultsCountThisPage: types.IntString(len(ids)),
+		PagesCount:           types.IntString(pages),
+		Page:                 types.IntString(page),
+		RID:                  set.rid,
+		SearchParams:         set.params,
+	}
+	if set.rid != "" {
+		response.RIDTTL = formatTTL(s.RIDTTL)
+	}
+
+	if yes(r.FormValue("keywords_list")) {
+		response.KeywordList = s.keywordList(ids)
+	}
+	if yes(r.FormValue("no_submissions")) {
+		return response, nil
+	}
+	for _, id := range ids {
+		e, ok := s.submissions[id]
+		if !ok {
+			continue
+		}
+		result := inkbunny.SubmissionSearch{SubmissionBasic: e.SubmissionBasic}
+		if yes(r.FormValue("submission_ids_only")) {
+			result = inkbunny.SubmissionSearch{SubmissionBasic: inkbunny.SubmissionBasic{SubmissionID: id}}
+		}
+		if t, ok := set.unread[id]; ok {
+			result.UnreadDateSystem = systemTime(t)
+			result.UnreadDateUser = formatUserTime(t)
+		}
+		if stars, ok := set.stars[id]; ok {
+			result.Stars = types.IntString(stars)
+		}
+		response.Submissions = append(response.Submissions, result)
+	}
+	return response, nil
+}
+
+// find runs a Mode 1 search and returns its results set.
+func (s *Server) find(r *http.Request, sess *session) *resultSet {
+	set := &resultSet{perPage: 30, params: searchParams(r)}
+
+	var favs *account
+	if id, err := strconv.Atoi(r.FormValue("favs_user_id")); err == nil {
+		favs = s.accountByID(types.IntString(id))
+		if favs == nil {
+			return set
+		}
+		set.stars = make(map[types.IntString]int)
+	}
+	unreadOnly := yes(r.FormValue("unread_submissions"))
+	if unreadOnly {
+		if sess.account == nil {
+			return set
+		}
+		set.unread = make(map[types.IntString]time.Time)
+	}
+
+	match := s.matcher(r)
+	var found []*entry
+	for _, e := range s.submissions {
+		if e.Deleted {
+			continue
+		}
+		owner := sess.account != nil && sess.account.UserID == e.UserID
+		if !bool(e.Public) && !(owner && (r.FormValue("user_id") != "" || r.FormValue("username") != "")) {
+			continue
+		}
+		if unreadOnly {
+			t, ok := sess.account.unread[e.SubmissionID]
+			if !ok {
+				continue
+			}
+			set.unread[e.SubmissionID] = t
+		} else if !owner && !s.visible(sess, e) {
+			continue
+		}
+		if favs != nil {
+			if _, ok := favs.favorites[e.SubmissionID]; !ok {
+				continue
+			}
+		}
+		if match(e) {
+			found = append(found, e)
+		}
+	}
+
+	order := r.FormValue("orderby")
+	slices.SortFunc(found, func(a, b *entry) int {
+		c := 0
+		switch order {
+		case types.OrderByLastFileUpdateDatetime:
+			c = b.UpdateDateSystem.Compare(a.UpdateDateSystem.Time)
+		case types.OrderByUnreadDatetime:
+			c = set.unread[b.SubmissionID].Compare(set.unread[a.SubmissionID])
+		case types.OrderByUnreadDatetimeReverse:
+			c = set.unread[a.SubmissionID].Compare(set.unread[b.SubmissionID])
+		case types.OrderByViews:
+			c = cmp.Compare(b.Views, a.Views)
+		case types.OrderByUsername:
+			c = cmp.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username))
+		case types.OrderByFavDatetime, types.OrderByFavStars:
+			if favs != nil {
+				c = favs.favorites[b.SubmissionID].Compare(favs.favorites[a.SubmissionID])
+			}
+		case types.OrderByFavs:
+			c = cmp.Compare(b.FavoritesCount, a.FavoritesCount)
+		case types.OrderByPoolOrder:
+			c = a.created.Compare(b.created)
+		}
+		if c == 0 {
+			c = b.created.Compare(a.created)
+		}
+		if c == 0 {
+			c = cmp.Compare(b.SubmissionID, a.SubmissionID)
+		}
+		return c
+	})
+
+	if limit, err := strconv.Atoi(r.FormValue("count_limit")); err == nil && limit > 0 {
+		found = found[:min(limit, len(found))]
+	}
+	if yes(r.FormValue("random")) {
+		rand.Shuffle(len(found), func(i, j int) { found[i], found[j] = found[j], found[i] })
+	}
+	for _, e := range found {
+		set.ids = append(set.ids, e.SubmissionID)
+		if favs != nil {
+			set.stars[e.SubmissionID] = 0
+		}
+	}
+	return set
+}
+
+// matcher returns a filter for the Mode 1 search conditions other than visibility, favorites and unread submissions.
+func (s *Server) matcher(r *http.Request) func(*entry) bool {
+	var filters []func(*entry) bool
+
+	if id, err := strconv.Atoi(r.FormValue("keyword_id")); err == nil {
+		filters = append(filters, func(e *entry) bool {
+			return slices.ContainsFunc(e.Keywords, func(k inkbunny.Keyword) bool { return k.KeywordID == types.IntString(id) })
+		})
+	} else if text := r.FormValue("text"); text != "" {
+		filters = append(filters, textFilter(r, text))
+	}
+	if username := r.FormValue("username"); username != "" {
+		filters = append(filters, func(e *entry) bool { return strings.EqualFold(e.Username, username) })
+	}
+	if id, err := strconv.Atoi(r.FormValue("user_id")); err == nil {
+		filters = append(filters, func(e *entry) bool { return e.UserID == types.IntString(id) })
+	}
+	if list := splitList(r.FormValue("type")); len(list) > 0 {
+		filters = append(filters, func(e *entry) bool { return slices.Contains(list, e.SubmissionTypeID.String()) })
+	}
+	if id, err := strconv.Atoi(r.FormValue("pool_id")); err == nil {
+		filters = append(filters, func(e *entry) bool {
+			return slices.ContainsFunc(e.Pools, func(p inkbunny.Pool) bool { return p.PoolID == types.IntString(id) })
+		})
+	}
+	switch r.FormValue("scraps") {
+	case inkbunny.ScrapsNo:
+		filters = append(filters, func(e *entry) bool { return !bool(e.Scraps) })
+	case inkbunny.ScrapsOnly:
+		filters = append(filters, func(e *entry) bool { return bool(e.Scraps) })
+	}
+	if days, err := strconv.Atoi(r.FormValue("dayslimit")); err == nil && days > 0 {
+		since := s.Now().AddDate(0, 0, -days)
+		filters = append(filters, func(e *entry) bool { return e.created.After(since) })
+	}
+
+	return func(e *entry) bool {
+		for _, f := range filters {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// textFilter matches text against the keywords, title, description and md5 fields chosen in the request.
+func textFilter(r *http.Request, text string) func(*entry) bool {
+	text = strings.ToLower(strings.NewReplacer("_", " ", ",", " ").Replace(text))
+	words := strings.Fields(text)
+	join := cmp.Or(r.FormValue("string_join_type"), types.JoinTypeAnd)
+
+	contains := func(field func(string) bool) bool {
+		switch join {
+		case types.JoinTypeOr:
+			return slices.ContainsFunc(words, field)
+		case types.JoinTypeExact:
+			return field(strings.Join(words, " "))
+		default:
+			for _, w := range words {
+				if !field(w) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	var fields []func(*entry) bool
+	if r.FormValue("keywords") == "" || yes(r.FormValue("keywords")) {
+		fields = append(fields, func(e *entry) bool {
+			return contains(func(w string) bool {
+				return slices.ContainsFunc(e.Keywords, func(k inkbunny.Keyword) bool { return strings.EqualFold(k.KeywordName, w) })
+			})
+		})
+	}
+	if yes(r.FormValue("title")) {
+		fields = append(fields, func(e *entry) bool {
+			return contains(func(w string) bool { return strings.Contains(strings.ToLower(e.Title), w) })
+		})
+	}
+	if yes(r.FormValue("description")) {
+		fields = append(fields, func(e *entry) bool {
+			return contains(func(w string) bool {
+				return strings.Contains(strings.ToLower(e.Description), w) || strings.Contains(strings.ToLower(e.Writing), w)
+			})
+		})
+	}
+	if yes(r.FormValue("md5")) {
+		fields = append(fields, func(e *entry) bool {
+			return slices.ContainsFunc(e.Files, func(f inkbunny.File) bool {
+				return slices.ContainsFunc(words, func(w string) bool {
+					return slices.Contains([]string{f.InitialFileMD5, f.FullFileMD5, f.LargeFileMD5, f.SmallFileMD5, f.ThumbnailMD5}, w)
+				})
+			})
+		})
+	}
+
+	return func(e *entry) bool {
+		if len(fields) == 0 {
+			return false
+		}
+		if r.FormValue("field_join_type") == types.FieldJoinTypeAnd {
+			for _, f := range fields {
+				if !f(e) {
+					return false
+				}
+			}
+			return true
+		}
+		return slices.ContainsFunc(fields, func(f func(*entry) bool) bool { return f(e) })
+	}
+}
+
+// keywordList returns the keywords of the given submissions, most used first.
+func (s *Server) keywordList(ids []types.IntString) []inkbunny.KeywordList {
+	var list []inkbunny.KeywordList
+	for _, id := range ids {
+		e, ok := s.submissions[id]
+		if !ok {
+			continue
+		}
+		for _, k := range e.Keywords {
+			i := slices.IndexFunc(list, func(l inkbunny.KeywordList) bool { return l.KeywordID == k.KeywordID })
+			if i < 0 {
+				list = append(list, inkbunny.KeywordList{KeywordID: k.KeywordID, KeywordName: k.KeywordName})
+				i = len(list) - 1
+			}
+			list[i].SubmissionsCount++
+		}
+	}
+	slices.SortStableFunc(list, func(a, b inkbunny.KeywordList) int {
+		return cmp.Or(cmp.Compare(b.SubmissionsCount, a.SubmissionsCount), cmp.Compare(a.KeywordName, b.KeywordName))
+	})
+	return list[:min(len(list), 100)]
+}
+
+// searchParams lists the Mode 1 parameters that were sent, like the search_params of the real API.
+func searchParams(r *http.Request) []inkbunny.SearchParam {
+	var params []inkbunny.SearchParam
+	for key, values := range r.Form {
+		switch key {
+		case "sid", "rid", "page", "submissions_per_page", "get_rid", "no_submissions", "keywords_list", "output_mode", "submission_ids_only":
+			continue
+		}
+		if len(values) == 0 || values[0] == "" {
+			continue
+		}
+		typ := "string"
+		if _, err := strconv.Atoi(values[0]); err == nil {
+			typ = "int"
+		} else if values[0] == "yes" || values[0] == "no" {
+			typ = "bool"
+		}
+		params = append(params, inkbunny.SearchParam{Name: key, Type: typ})
+	}
+	slices.SortFunc(params, func(a, b inkbunny.SearchParam) int { return cmp.Compare(a.Name, b.Name) })
+	return params
+}
+
+func (s *Server) accountByID(id types.IntString) *account {
+	for _, a := range s.accounts {
+		if a.UserID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// formatTTL formats a duration like the rid_ttl of the real API, e.g. "15 minutes".
+func formatTTL(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	}
+	return fmt.Sprintf("%d seconds", d/time.Second)
+}
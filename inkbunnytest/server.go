@@ -0,0 +1,493 @@
+// Package inkbunnytest provides an in-memory fake of the Inkbunny API for testing code that uses inkbunny.Client.
+//
+//	server := inkbunnytest.NewServer()
+//	defer server.Close()
+//
+//	server.AddUser("artist", "password")
+//	server.AddSubmission(inkbunny.SubmissionDetails{
+//		SubmissionBasic: inkbunny.SubmissionBasic{Username: "artist", Title: "Fox"},
+//		Keywords:        []inkbunny.Keyword{{KeywordName: "fox"}},
+//	})
+//
+//	client := server.Client()
+//	user, err := client.Login("artist", "password")
+package inkbunnytest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// Server is a fake Inkbunny API backed by an in-memory dataset.
+// It implements the login, logout, userrating, search, submissions, submissionfavingusers, watchlist,
+// username_autosuggest, search_autosuggest, upload, editsubmission, delsubmission, delfile and reorderfile endpoints,
+// and serves the content of uploaded files under /files/.
+//
+// Errors are reported with the same codes as the real API, see types.ErrorCode.
+// Searches with types.OrderByPoolOrder return the submissions of a pool by creation date, oldest first.
+type Server struct {
+	*httptest.Server
+
+	// RIDTTL is how long a results set is kept after it was last accessed. Defaults to 15 minutes.
+	RIDTTL time.Duration
+	// SessionTTL is how long a session is kept after it was last used. Zero means sessions never expire.
+	SessionTTL time.Duration
+	// MaxSubmissionIDs is the maximum number of submission IDs api_submissions.php accepts. Defaults to 100.
+	MaxSubmissionIDs int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu          sync.Mutex
+	accounts    map[string]*account
+	sessions    map[string]*session
+	submissions map[types.IntString]*entry
+	results     map[string]*resultSet
+	files       map[types.IntString][]byte
+	failures    map[string][]types.ErrorCode
+	calls       map[string]int
+	lastID      int
+}
+
+// Account is a registered user of the Server.
+type Account struct {
+	UserID   types.IntString
+	Username string
+	Password string
+	// Ratings are the allowed ratings of new sessions. Defaults to all ratings.
+	Ratings types.Ratings
+	// CanUpload allows the account to upload. Defaults to true for accounts added with AddUser.
+	CanUpload bool
+}
+
+// Dataset is a set of accounts and submissions that can be loaded with Server.Seed.
+type Dataset struct {
+	Accounts    []Account
+	Submissions []inkbunny.SubmissionDetails
+	// Files maps file IDs to their content, served under File.FileURLFull.
+	Files map[types.IntString][]byte
+}
+
+type account struct {
+	Account
+	watching  []types.IntString
+	favorites map[types.IntString]time.Time
+	unread    map[types.IntString]time.Time
+}
+
+type session struct {
+	sid      string
+	account  *account // nil for guests
+	ratings  types.Ratings
+	lastUsed time.Time
+}
+
+type entry struct {
+	inkbunny.SubmissionDetails
+	created time.Time
+}
+
+type resultSet struct {
+	rid        string
+	ids        []types.IntString
+	unread     map[types.IntString]time.Time
+	stars      map[types.IntString]int
+	perPage    int
+	lastAccess time.Time
+	params     []inkbunny.SearchParam
+}
+
+// NewServer starts and returns a new Server with an empty dataset. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		RIDTTL:           15 * time.Minute,
+		MaxSubmissionIDs: 100,
+		Now:              time.Now,
+		accounts:         make(map[string]*account),
+		sessions:         make(map[string]*session),
+		submissions:      make(map[types.IntString]*entry),
+		results:          make(map[string]*resultSet),
+		files:            make(map[types.IntString][]byte),
+		failures:         make(map[string][]types.ErrorCode),
+		calls:            make(map[string]int),
+	}
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+// Client returns an inkbunny.Client that sends its requests to the Server.
+// Additional options are applied after the base URL and http.Client are set.
+func (s *Server) Client(opts ...func(*inkbunny.Client)) *inkbunny.Client {
+	base, _ := url.Parse(s.URL)
+	return inkbunny.NewClient(append([]func(*inkbunny.Client){
+		inkbunny.WithBaseURL(base),
+		inkbunny.WithClient(s.Server.Client()),
+	}, opts...)...)
+}
+
+// Seed loads a Dataset into the Server.
+func (s *Server) Seed(data Dataset) {
+	for _, a := range data.Accounts {
+		s.AddAccount(a)
+	}
+	for _, sub := range data.Submissions {
+		s.AddSubmission(sub)
+	}
+	for id, content := range data.Files {
+		s.SetFileContent(id, content)
+	}
+}
+
+// AddUser registers an account that can log in and upload, and returns its user ID.
+func (s *Server) AddUser(username, password string) types.IntString {
+	return s.AddAccount(Account{Username: username, Password: password, CanUpload: true})
+}
+
+// AddAccount registers an account and returns its user ID, which is assigned if Account.UserID is zero.
+func (s *Server) AddAccount(a Account) types.IntString {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.UserID == 0 {
+		a.UserID = s.nextID()
+	}
+	if a.Ratings == (types.Ratings{}) {
+		a.Ratings = types.ParseMask("11111")
+	}
+	s.accounts[strings.ToLower(a.Username)] = &account{
+		Account:   a,
+		favorites: make(map[types.IntString]time.Time),
+		unread:    make(map[types.IntString]time.Time),
+	}
+	return a.UserID
+}
+
+// AddSubmission adds a submission and returns its ID.
+// Missing IDs, the owner's UserID, dates, file URLs, counts and public status are filled in.
+// Submissions owned by an unknown Username create a new account without a password.
+func (s *Server) AddSubmission(sub inkbunny.SubmissionDetails) types.IntString {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addSubmission(sub, true)
+}
+
+func (s *Server) addSubmission(sub inkbunny.SubmissionDetails, public bool) types.IntString {
+	if sub.SubmissionID == 0 {
+		sub.SubmissionID = s.nextID()
+	}
+	owner := s.accounts[strings.ToLower(sub.Username)]
+	if owner == nil && sub.Username != "" {
+		owner = &account{
+			Account:   Account{UserID: s.nextID(), Username: sub.Username},
+			favorites: make(map[types.IntString]time.Time),
+			unread:    make(map[types.IntString]time.Time),
+		}
+		s.accounts[strings.ToLower(sub.Username)] = owner
+	}
+	if owner != nil {
+		sub.UserID = owner.UserID
+		sub.Username = owner.Username
+	}
+	created := s.Now()
+	if !sub.CreateDateSystem.IsZero() {
+		created = sub.CreateDateSystem.Time
+	}
+	sub.CreateDateSystem = systemTime(created)
+	sub.CreateDateUser = formatUserTime(created)
+	if sub.SubmissionTypeID == 0 {
+		sub.SubmissionTypeID = types.IntString(inkbunny.SubmissionTypePicturePinup)
+	}
+	if sub.TypeName == "" {
+		sub.TypeName = typeNames[inkbunny.SubmissionType(sub.SubmissionTypeID)]
+	}
+	if !bool(sub.Public) && public {
+		sub.Public = types.Yes
+	}
+	for i := range sub.Keywords {
+		if sub.Keywords[i].KeywordID == 0 {
+			sub.Keywords[i].KeywordID = s.keywordID(sub.Keywords[i].KeywordName)
+		}
+	}
+	e := &entry{SubmissionDetails: sub, created: created}
+	for i := range e.Files {
+		s.initFile(e, &e.Files[i], i)
+	}
+	s.refresh(e)
+	s.submissions[sub.SubmissionID] = e
+	return sub.SubmissionID
+}
+
+// initFile fills in the IDs, order, URLs and dates of a file.
+func (s *Server) initFile(e *entry, f *inkbunny.File, order int) {
+	if f.FileID == 0 {
+		f.FileID = s.nextID()
+	}
+	f.SubmissionID = e.SubmissionID
+	f.UserID = e.UserID
+	f.SubmissionFileOrder = types.IntString(order)
+	if f.FileName == "" {
+		f.FileName = fmt.Sprintf("%d_%s_file.png", f.FileID, e.Username)
+	}
+	if f.MimeType == "" {
+		f.MimeType = "image/png"
+	}
+	if f.CreateDateTime.IsZero() {
+		f.CreateDateTime = systemTime(s.Now())
+		f.CreateDateTimeUser = formatUserTime(s.Now())
+	}
+	if f.FileURLFull == "" {
+		f.FileURLFull = fmt.Sprintf("%s/files/full/%d/%s", s.URL, f.FileID, f.FileName)
+		f.FileURLScreen = fmt.Sprintf("%s/files/screen/%d/%s", s.URL, f.FileID, f.FileName)
+		f.FileURLPreview = fmt.Sprintf("%s/files/preview/%d/%s", s.URL, f.FileID, f.FileName)
+		f.ThumbnailURLMedium = fmt.Sprintf("%s/files/thumbnail/%d/%s", s.URL, f.FileID, f.FileName)
+	}
+}
+
+// refresh updates the fields of a submission that are derived from its files, keywords and ratings.
+func (s *Server) refresh(e *entry) {
+	live := slices.DeleteFunc(slices.Clone(e.Files), func(f inkbunny.File) bool { return bool(f.Deleted) })
+	slices.SortStableFunc(live, func(a, b inkbunny.File) int { return int(a.SubmissionFileOrder - b.SubmissionFileOrder) })
+	for i := range e.Files {
+		for j, f := range live {
+			if f.FileID == e.Files[i].FileID {
+				e.Files[i].SubmissionFileOrder = types.IntString(j)
+			}
+		}
+	}
+	slices.SortStableFunc(e.Files, func(a, b inkbunny.File) int { return int(a.SubmissionFileOrder - b.SubmissionFileOrder) })
+	e.PageCount = types.IntString(len(live))
+	if len(live) > 0 {
+		first, latest := live[0], live[0]
+		for _, f := range live {
+			if f.CreateDateTime.After(latest.CreateDateTime.Time) {
+				latest = f
+			}
+		}
+		e.FileName = first.FileName
+		e.MimeType = first.MimeType
+		e.FileURL = first.FileURL
+		e.Thumbs = first.Thumbs
+		e.LatestFileName = latest.FileName
+		e.LatestMimeType = latest.MimeType
+		e.LatestFileURL = inkbunny.LatestFileURL{
+			LatestFileURLFull:    latest.FileURLFull,
+			LatestFileURLScreen:  latest.FileURLScreen,
+			LatestFileURLPreview: latest.FileURLPreview,
+		}
+		e.UpdateDateSystem = latest.CreateDateTime
+		e.UpdateDateUser = latest.CreateDateTimeUser
+	}
+	e.RatingID, e.RatingName = ratingOf(e.Ratings)
+	e.PoolsCount = len(e.Pools)
+}
+
+// SetFileContent sets the content served for a file, which is also used to compute its MD5 hashes.
+func (s *Server) SetFileContent(fileID types.IntString, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[fileID] = content
+	for _, e := range s.submissions {
+		for i := range e.Files {
+			if e.Files[i].FileID == fileID {
+				s.hashFile(&e.Files[i], content)
+			}
+		}
+	}
+}
+
+// hashFile stores the content of a file and sets its MD5 hashes.
+func (s *Server) hashFile(f *inkbunny.File, content []byte) {
+	s.files[f.FileID] = content
+	sum := md5.Sum(content)
+	hash := hex.EncodeToString(sum[:])
+	f.InitialFileMD5 = hash
+	f.FullFileMD5 = hash
+	f.LargeFileMD5 = hash
+	f.SmallFileMD5 = hash
+	f.ThumbnailMD5 = hash
+}
+
+// Submission returns the current state of a submission, including unpublished and deleted ones.
+func (s *Server) Submission(id types.IntString) (inkbunny.SubmissionDetails, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.submissions[id]
+	if !ok {
+		return inkbunny.SubmissionDetails{}, false
+	}
+	return e.SubmissionDetails, true
+}
+
+// Watch makes username watch each of the watched users.
+func (s *Server) Watch(username string, watched ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.accounts[strings.ToLower(username)]
+	if a == nil {
+		return
+	}
+	for _, w := range watched {
+		if target := s.accounts[strings.ToLower(w)]; target != nil {
+			a.watching = append(a.watching, target.UserID)
+		}
+	}
+}
+
+// Favorite adds a submission to the favorites of username.
+func (s *Server) Favorite(username string, id types.IntString) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.accounts[strings.ToLower(username)]
+	e := s.submissions[id]
+	if a == nil || e == nil {
+		return
+	}
+	if _, ok := a.favorites[id]; !ok {
+		a.favorites[id] = s.Now()
+		e.FavoritesCount++
+	}
+}
+
+// MarkUnread adds a submission to the unread submissions of username.
+func (s *Server) MarkUnread(username string, id types.IntString) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.accounts[strings.ToLower(username)]; a != nil {
+		a.unread[id] = s.Now()
+	}
+}
+
+// ExpireSessions invalidates every session, so that the next request fails with types.ErrInvalidSessionID.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.sessions)
+}
+
+// ExpireResults removes every results set, so that the next request using an RID fails with types.ErrNoResultsFound.
+func (s *Server) ExpireResults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.results)
+}
+
+// FailNext makes the next requests to endpoint fail with the given codes, in order.
+// endpoint is the name used by inkbunny.ApiUrl, e.g. "search" for api_search.php.
+// types.ErrUnexpectedStatus responds with a 500 Internal Server Error instead.
+func (s *Server) FailNext(endpoint string, codes ...types.ErrorCode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = append(s.failures[endpoint], codes...)
+}
+
+// Calls returns how many requests were made to endpoint, e.g. "search" for api_search.php.
+func (s *Server) Calls(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[endpoint]
+}
+
+func (s *Server) nextID() types.IntString {
+	s.lastID++
+	return types.IntString(s.lastID)
+}
+
+// keywordID returns the ID already used for a keyword name, or a new one.
+func (s *Server) keywordID(name string) types.IntString {
+	for _, e := range s.submissions {
+		for _, k := range e.Keywords {
+			if strings.EqualFold(k.KeywordName, name) && k.KeywordID != 0 {
+				return k.KeywordID
+			}
+		}
+	}
+	return s.nextID()
+}
+
+// newSession creates a session for a (nil for guests) and returns it.
+func (s *Server) newSession(a *account) *session {
+	sid := fmt.Sprintf("%x", md5.Sum(fmt.Appendf(nil, "%d-%d", s.nextID(), s.Now().UnixNano())))
+	sess := &session{sid: sid, account: a, lastUsed: s.Now()}
+	if a != nil {
+		sess.ratings = a.Ratings
+	} else {
+		sess.ratings = types.ParseMask("101")
+	}
+	s.sessions[sid] = sess
+	return sess
+}
+
+var typeNames = map[inkbunny.SubmissionType]string{
+	inkbunny.SubmissionTypePicturePinup:              "Picture/Pinup",
+	inkbunny.SubmissionTypeSketch:                    "Sketch",
+	inkbunny.SubmissionTypePictureSeries:             "Picture Series",
+	inkbunny.SubmissionTypeComic:                     "Comic",
+	inkbunny.SubmissionTypePortfolio:                 "Portfolio",
+	inkbunny.SubmissionTypeShockwaveFlashAnimation:   "Shockwave/Flash - Animation",
+	inkbunny.SubmissionTypeShockwaveFlashInteractive: "Shockwave/Flash - Interactive",
+	inkbunny.SubmissionTypeVideoFeatureLength:        "Video - Feature Length",
+	inkbunny.SubmissionTypeVideoAnimation3DCGI:       "Video - Animation/3D/CGI",
+	inkbunny.SubmissionTypeMusicSingleTrack:          "Music - Single Track",
+	inkbunny.SubmissionTypeMusicAlbum:                "Music - Album",
+	inkbunny.SubmissionTypeWritingDocument:           "Writing - Document",
+	inkbunny.SubmissionTypeCharacterSheet:            "Character Sheet",
+	inkbunny.SubmissionTypePhotography:               "Photography - Fursuit/Sculpture/Jewelry/etc",
+}
+
+// ratingOf returns the rating_id and rating_name of a submission based on its content tags.
+func ratingOf(ratings []inkbunny.SubmissionRating) (types.IntString, string) {
+	rating := types.RatingGeneral
+	for _, r := range ratings {
+		switch r.Tag() {
+		case types.ContentTagNudity, types.ContentTagMildViolence:
+			rating = max(rating, types.RatingMature)
+		case types.ContentTagSexual, types.ContentTagStrongViolence:
+			rating = types.RatingAdult
+		}
+	}
+	return types.IntString(rating), rating.String()
+}
+
+const userLayout = "02 Jan 2006 15:04 MST"
+
+// systemTime returns t in UTC with the precision of the API.
+func systemTime(t time.Time) types.Time {
+	return types.Time{Time: t.UTC().Truncate(time.Microsecond)}
+}
+
+func formatUserTime(t time.Time) string {
+	return t.UTC().Format(userLayout)
+}
+
+// serveFile serves the content of a file set with SetFileContent or uploaded, supporting Range requests.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/files/"), "/")
+	if len(parts) < 3 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	content, ok := s.files[types.IntString(id)]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, parts[2], time.Time{}, bytes.NewReader(content))
+}
@@ -0,0 +1,389 @@
+package inkbunnytest_test
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/inkbunnytest"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// newServer returns a Server with the user "artist" and n of their submissions, the newest first in ids.
+func newServer(t *testing.T, n int) (*inkbunnytest.Server, []types.IntString) {
+	t.Helper()
+	server := inkbunnytest.NewServer()
+	t.Cleanup(server.Close)
+	server.AddUser("artist", "password")
+	start := time.Now().Add(-time.Duration(n) * time.Minute)
+	ids := make([]types.IntString, n)
+	for i := range n {
+		ids[n-1-i] = server.AddSubmission(inkbunny.SubmissionDetails{SubmissionBasic: inkbunny.SubmissionBasic{
+			Username:         "artist",
+			Title:            fmt.Sprintf("Submission %d", i),
+			CreateDateSystem: types.Time{Time: start.Add(time.Duration(i) * time.Minute)},
+		}})
+	}
+	return server, ids
+}
+
+func login(t *testing.T, client *inkbunny.Client) *inkbunny.User {
+	t.Helper()
+	user, err := client.Login("artist", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestLogin(t *testing.T) {
+	server, _ := newServer(t, 0)
+	client := server.Client()
+
+	user := login(t, client)
+	if user.SID == "" || user.UserID == 0 || user.Username != "artist" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	_, err := client.Login("artist", "wrong")
+	if !errors.Is(err, types.ErrInvalidLogin) {
+		t.Errorf("wrong password: got %v, want ErrInvalidLogin", err)
+	}
+	if calls := server.Calls("login"); calls != 2 {
+		t.Errorf("got %d calls to login, want 2", calls)
+	}
+}
+
+func TestExpireSessions(t *testing.T) {
+	t.Run("without credentials", func(t *testing.T) {
+		server, _ := newServer(t, 1)
+		user := login(t, server.Client())
+		server.ExpireSessions()
+
+		_, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "artist"})
+		if !errors.Is(err, types.ErrInvalidSessionID) {
+			t.Errorf("got %v, want ErrInvalidSessionID", err)
+		}
+	})
+
+	t.Run("with credentials", func(t *testing.T) {
+		server, _ := newServer(t, 1)
+		user := login(t, server.Client(inkbunny.WithCredentials(inkbunny.StaticCredentials("artist", "password"))))
+		expired := user.SID
+		server.ExpireSessions()
+
+		response, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "artist"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Submissions) != 1 {
+			t.Errorf("got %d submissions, want 1", len(response.Submissions))
+		}
+		if user.SID == expired {
+			t.Error("SID was not replaced")
+		}
+		if calls := server.Calls("login"); calls != 2 {
+			t.Errorf("got %d calls to login, want 2", calls)
+		}
+		if calls := server.Calls("search"); calls != 2 {
+			t.Errorf("got %d calls to search, want 2", calls)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		server, _ := newServer(t, 1)
+		user := login(t, server.Client(inkbunny.WithCredentials(inkbunny.StaticCredentials("artist", "password"))))
+		server.ExpireSessions()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "artist"})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		if calls := server.Calls("login"); calls != 2 {
+			t.Errorf("got %d calls to login, want 2", calls)
+		}
+	})
+
+	t.Run("ratings", func(t *testing.T) {
+		server, _ := newServer(t, 1)
+		user := login(t, server.Client(inkbunny.WithCredentials(inkbunny.StaticCredentials("artist", "password"))))
+		general := types.Ratings{General: types.Address(types.Yes)}
+		if err := user.ChangeRatings(general); err != nil {
+			t.Fatal(err)
+		}
+		server.ExpireSessions()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if i%2 == 0 {
+					errs <- user.ChangeRatings(general)
+					return
+				}
+				_, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "artist"})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		if calls := server.Calls("login"); calls != 2 {
+			t.Errorf("got %d calls to login, want 2", calls)
+		}
+		if user.Ratings.String() != types.ParseMaskU(general.Byte()).String() {
+			t.Errorf("got ratings %s, want %s", user.Ratings, types.ParseMaskU(general.Byte()))
+		}
+	})
+}
+
+func TestExpireResults(t *testing.T) {
+	server, ids := newServer(t, 25)
+	user := login(t, server.Client())
+
+	response, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{
+		Username:           "artist",
+		SubmissionsPerPage: 10,
+		GetRID:             types.Yes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.RID == "" {
+		t.Fatal("no RID")
+	}
+
+	var got []types.IntString
+	for page, err := range response.Paginate().Pages() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, submission := range page.Submissions {
+			got = append(got, submission.SubmissionID)
+		}
+		if page.Page == 1 {
+			server.ExpireResults()
+		}
+	}
+	if !slices.Equal(got, ids) {
+		t.Errorf("got %v, want %v", got, ids)
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	t.Run("error code", func(t *testing.T) {
+		server, _ := newServer(t, 1)
+		user := login(t, server.Client())
+		server.FailNext("search", types.ErrDatabaseError)
+
+		_, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "artist"})
+		if !errors.Is(err, types.ErrDatabaseError) {
+			t.Errorf("got %v, want ErrDatabaseError", err)
+		}
+		if _, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "artist"}); err != nil {
+			t.Errorf("second request: %v", err)
+		}
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		server, _ := newServer(t, 1)
+		user := login(t, server.Client(inkbunny.WithRetry(inkbunny.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})))
+		server.FailNext("search", types.ErrUnexpectedStatus, types.ErrUnexpectedStatus)
+
+		response, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "artist"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Submissions) != 1 {
+			t.Errorf("got %d submissions, want 1", len(response.Submissions))
+		}
+		if calls := server.Calls("search"); calls != 3 {
+			t.Errorf("got %d calls to search, want 3", calls)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		server, _ := newServer(t, 1)
+		user := login(t, server.Client(inkbunny.WithRetry(inkbunny.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})))
+		server.FailNext("search", types.ErrUnexpectedStatus, types.ErrUnexpectedStatus)
+
+		_, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{Username: "artist"})
+		if !errors.Is(err, types.ErrUnexpectedStatus) {
+			t.Errorf("got %v, want ErrUnexpectedStatus", err)
+		}
+		if calls := server.Calls("search"); calls != 2 {
+			t.Errorf("got %d calls to search, want 2", calls)
+		}
+	})
+}
+
+func TestSubmissionDetailsChunks(t *testing.T) {
+	server, ids := newServer(t, 25)
+	server.MaxSubmissionIDs = 10
+	user := login(t, server.Client())
+
+	request := inkbunny.SubmissionDetailsRequest{ChunkSize: 10}
+	for _, id := range ids {
+		request.SubmissionIDSlice = append(request.SubmissionIDSlice, id.String())
+	}
+	response, err := user.SubmissionDetails(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []types.IntString
+	for _, submission := range response.Submissions {
+		got = append(got, submission.SubmissionID)
+	}
+	if !slices.Equal(got, ids) {
+		t.Errorf("got %v, want %v", got, ids)
+	}
+	if calls := server.Calls("submissions"); calls != 3 {
+		t.Errorf("got %d calls to submissions, want 3", calls)
+	}
+
+	server.FailNext("submissions", types.ErrDatabaseError)
+	response, err = user.SubmissionDetails(request)
+	var chunkErr *inkbunny.ChunkError
+	if !errors.As(err, &chunkErr) || !errors.Is(err, types.ErrDatabaseError) {
+		t.Errorf("got %v, want a ChunkError", err)
+	}
+	if len(response.Submissions) != 15 {
+		t.Errorf("got %d submissions from the other chunks, want 15", len(response.Submissions))
+	}
+}
+
+func TestStreamSubmissionDetails(t *testing.T) {
+	server, ids := newServer(t, 25)
+	user := login(t, server.Client(inkbunny.WithCredentials(inkbunny.StaticCredentials("artist", "password"))))
+	server.ExpireSessions()
+
+	request := inkbunny.SubmissionDetailsRequest{ChunkSize: 10}
+	for _, id := range ids {
+		request.SubmissionIDSlice = append(request.SubmissionIDSlice, id.String())
+	}
+	var got []types.IntString
+	for submission, err := range user.StreamSubmissionDetails(request) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, submission.SubmissionID)
+	}
+	if !slices.Equal(got, ids) {
+		t.Errorf("got %v, want %v", got, ids)
+	}
+}
+
+func TestPages(t *testing.T) {
+	const pages = 5
+	for _, test := range []struct {
+		name  string
+		rid   types.BooleanYN
+		pages func(inkbunny.SubmissionSearchResponse) iter.Seq2[inkbunny.SubmissionSearchResponse, error]
+	}{
+		{"Paginate", types.Yes, func(r inkbunny.SubmissionSearchResponse) iter.Seq2[inkbunny.SubmissionSearchResponse, error] {
+			return r.Paginate().Pages()
+		}},
+		{"AllPages", types.Yes, inkbunny.SubmissionSearchResponse.AllPages},
+		{"without RID", types.No, inkbunny.SubmissionSearchResponse.AllPages},
+		{"Prefetch", types.Yes, func(r inkbunny.SubmissionSearchResponse) iter.Seq2[inkbunny.SubmissionSearchResponse, error] {
+			return r.Paginate().Prefetch(3).Pages()
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			server, ids := newServer(t, pages*10-3)
+			user := login(t, server.Client())
+
+			response, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{
+				Username:           "artist",
+				SubmissionsPerPage: 10,
+				GetRID:             test.rid,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.PagesCount != pages {
+				t.Fatalf("got %d pages, want %d", response.PagesCount, pages)
+			}
+
+			var got []types.IntString
+			var numbers []types.IntString
+			for page, err := range test.pages(response) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				numbers = append(numbers, page.Page)
+				for _, submission := range page.Submissions {
+					got = append(got, submission.SubmissionID)
+				}
+			}
+			if want := []types.IntString{1, 2, 3, 4, 5}; !slices.Equal(numbers, want) {
+				t.Errorf("got pages %v, want %v", numbers, want)
+			}
+			if !slices.Equal(got, ids) {
+				t.Errorf("got %v, want %v", got, ids)
+			}
+			if calls := server.Calls("search"); calls != pages {
+				t.Errorf("got %d calls to search, want %d", calls, pages)
+			}
+		})
+	}
+}
+
+func TestExpireResultsPrefetch(t *testing.T) {
+	server, ids := newServer(t, 50)
+	user := login(t, server.Client())
+
+	response, err := user.SearchSubmissions(inkbunny.SubmissionSearchRequest{
+		Username:           "artist",
+		SubmissionsPerPage: 10,
+		GetRID:             types.Yes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.ExpireResults()
+
+	var got []types.IntString
+	rids := make(map[string]bool)
+	for page, err := range response.Paginate().Prefetch(4).Pages() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Page > 1 {
+			rids[page.RID] = true
+		}
+		for _, submission := range page.Submissions {
+			got = append(got, submission.SubmissionID)
+		}
+	}
+	if !slices.Equal(got, ids) {
+		t.Errorf("got %v, want %v", got, ids)
+	}
+	if len(rids) != 1 || rids[response.RID] {
+		t.Errorf("got RIDs %v after the first page, want a single new RID", rids)
+	}
+}
@@ -1,6 +1,8 @@
 package inkbunny
 
 import (
+	"context"
+
 	"github.com/ellypaws/inkbunny/types"
 	"github.com/ellypaws/inkbunny/utils"
 )
@@ -27,6 +29,11 @@ func (u *User) KeywordSuggestion(keyword string, ratings types.Ratings, undersco
 	return u.Client().KeywordSuggestion(keyword, ratings, underscore)
 }
 
+// KeywordSuggestionContext is like User.KeywordSuggestion but uses ctx for the request.
+func (u *User) KeywordSuggestionContext(ctx context.Context, keyword string, ratings types.Ratings, underscore bool) ([]KeywordAutocomplete, error) {
+	return u.Client().KeywordSuggestionContext(ctx, keyword, ratings, underscore)
+}
+
 // KeywordSuggestion suggests keywords based on partial keyword names entered by the user. It searches the start of keywords for the matching strings. It returns multiple matching suggestions as well as a count of the number of submissions each suggestion would find.
 // The HTML response header will contain a directive for your client to cache the result data for 1 day, if it supports caching.
 //   - All results are returned with HTML entities encoded. Eg: & will appear as &amp;, > will appear as &gt;, etc.
@@ -35,7 +42,14 @@ func (u *User) KeywordSuggestion(keyword string, ratings types.Ratings, undersco
 //   - Entering multiple words will search for words matching the last word, then matching the last two words, and so on. So entering "my li" will first search for all matches for "li" (lion, little, lizard...) and then all matches for "my li" (my little pony). This is similar to the keyword suggestion method Google uses and is most suitable for search boxes where users are likely to be entering multiple words. Eg: https://inkbunny.net/api_search_autosuggest.php?keyword=my+li&ratingsmask=11111&output_mode=xml
 //   - To force the search to treat a set of words as one keyword only, they must be joined with underscores and the "underscorespaces" parameter must be set to yes. Eg: "my_li" will then only return a result for "my little pony". https://inkbunny.net/api_search_autosuggest.php?keyword=my_li&underscorespaces=yes&ratingsmask=11111
 //   - Keywords are filtered roughly by user ratings settings. So keywords that generally appear on mature/adult rated images will be hidden from users who have those higher ratings turned off. You must send the user ratings selection (see "ratingsmask" parameter below) or the default "G rated only" will be used. Eg: https://inkbunny.net/api_search_autosuggest.php?keyword=hu&ratingsmask=11100&output_mode=xml
+//
+// If every field of ratings is unset, no ratingsmask is sent and the API uses "G rated only". Ratings with every field set to No are sent as "00000".
 func (c *Client) KeywordSuggestion(keyword string, ratings types.Ratings, underscore bool) ([]KeywordAutocomplete, error) {
+	return c.KeywordSuggestionContext(c.Context(), keyword, ratings, underscore)
+}
+
+// KeywordSuggestionContext is like Client.KeywordSuggestion but uses ctx for the request.
+func (c *Client) KeywordSuggestionContext(ctx context.Context, keyword string, ratings types.Ratings, underscore bool) ([]KeywordAutocomplete, error) {
 	type params struct {
 		Keyword          string          `json:"keyword"`
 		Ratings          types.Ratings   `json:"ratingsmask"`
@@ -49,7 +63,7 @@ func (c *Client) KeywordSuggestion(keyword string, ratings types.Ratings, unders
 	type results struct {
 		Results []KeywordAutocomplete `json:"results"`
 	}
-	response, err := PostDecode[results](c, ApiUrl("search_autosuggest"), utils.StructToUrlValues(param))
+	response, err := PostDecodeContext[results](ctx, c, c.ApiUrl("search_autosuggest"), utils.StructToUrlValues(param))
 	return response.Results, err
 }
 
@@ -64,3 +78,8 @@ func (c *Client) KeywordSuggestion(keyword string, ratings types.Ratings, unders
 func KeywordSuggestion(keyword string, ratings types.Ratings, underscore bool) ([]KeywordAutocomplete, error) {
 	return DefaultClient.KeywordSuggestion(keyword, ratings, underscore)
 }
+
+// KeywordSuggestionContext is like KeywordSuggestion but uses ctx for the request.
+func KeywordSuggestionContext(ctx context.Context, keyword string, ratings types.Ratings, underscore bool) ([]KeywordAutocomplete, error) {
+	return DefaultClient.KeywordSuggestionContext(ctx, keyword, ratings, underscore)
+}
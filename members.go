@@ -1,16 +1,22 @@
 package inkbunny
 
 import (
+	"context"
 	"net/url"
 
 	"github.com/ellypaws/inkbunny/types"
 )
 
 func (c *Client) SearchMembers(username string) ([]types.Autocomplete, error) {
+	return c.SearchMembersContext(c.Context(), username)
+}
+
+// SearchMembersContext is like Client.SearchMembers but uses ctx for the request.
+func (c *Client) SearchMembersContext(ctx context.Context, username string) ([]types.Autocomplete, error) {
 	type results struct {
 		Results []types.Autocomplete `json:"results" query:"results"`
 	}
-	response, err := PostDecode[results](c, ApiUrl("username_autosuggest"), url.Values{"username": {username}})
+	response, err := PostDecodeContext[results](ctx, c, c.ApiUrl("username_autosuggest"), url.Values{"username": {username}})
 	return response.Results, err
 }
 
@@ -18,18 +24,35 @@ func (u *User) SearchMembers(username string) ([]types.Autocomplete, error) {
 	return u.Client().SearchMembers(username)
 }
 
+// SearchMembersContext is like User.SearchMembers but uses ctx for the request.
+func (u *User) SearchMembersContext(ctx context.Context, username string) ([]types.Autocomplete, error) {
+	return u.Client().SearchMembersContext(ctx, username)
+}
+
 func SearchMembers(username string) ([]types.Autocomplete, error) {
 	return DefaultClient.SearchMembers(username)
 }
 
+// SearchMembersContext is like SearchMembers but uses ctx for the request.
+func SearchMembersContext(ctx context.Context, username string) ([]types.Autocomplete, error) {
+	return DefaultClient.SearchMembersContext(ctx, username)
+}
+
 // GetWatching gets the watchlist of a logged-in user
 func (u *User) GetWatching() ([]types.UsernameID, error) {
-	if u.SID == "" {
+	return u.GetWatchingContext(u.Client().Context())
+}
+
+// GetWatchingContext is like User.GetWatching but uses ctx for the request.
+func (u *User) GetWatchingContext(ctx context.Context) ([]types.UsernameID, error) {
+	if u.sid() == "" {
 		return nil, ErrNotLoggedIn
 	}
 	type results struct {
 		Watches []types.UsernameID `json:"watches"`
 	}
-	response, err := PostDecode[results](u.Client(), ApiUrl("watchlist"), url.Values{"sid": {u.SID}})
+	response, err := withSession(ctx, u, u.sid(), func(sid string) (results, error) {
+		return PostDecodeContext[results](ctx, u.Client(), u.Client().ApiUrl("watchlist"), url.Values{"sid": {sid}})
+	})
 	return response.Watches, err
 }
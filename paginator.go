@@ -0,0 +1,275 @@
+package inkbunny
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// ErrRIDExpired is returned by a Paginator when the results set of a search expired before all pages were fetched,
+// and the search could not be run again.
+// It also matches types.ErrNoResultsFound when the API reported the expiry.
+var ErrRIDExpired = errors.New("results ID expired")
+
+// Paginator pages through the results of a submission search, using the Client (and User, if any) that ran it.
+//
+// When the search returned an RID, the following pages are requested from that results set (Mode 2).
+// Otherwise the original search is run again for each page (Mode 1).
+//
+// If the results set expires while paging, the original search is run again with GetRID set to types.Yes,
+// and paging resumes from the same page of the new results set. Submissions that were already returned are skipped.
+// Only searches that ran in Mode 1 can be regenerated, otherwise paging stops with ErrRIDExpired.
+type Paginator struct {
+	client   *Client
+	user     *User
+	request  SubmissionSearchRequest
+	first    SubmissionSearchResponse
+	prefetch int
+}
+
+// Paginate returns a Paginator that starts from this response.
+func (s SubmissionSearchResponse) Paginate() *Paginator {
+	return &Paginator{
+		client:  s.client,
+		user:    s.user,
+		request: s.request,
+		first:   s,
+	}
+}
+
+// Prefetch fetches up to n pages concurrently while iterating. Pages are still yielded in order.
+// Requests go through the rate limiters of the Client, and stop when the context is cancelled or the iteration stops.
+// A value of 1 or less fetches the pages one at a time, which is the default.
+func (p *Paginator) Prefetch(n int) *Paginator {
+	p.prefetch = n
+	return p
+}
+
+// Pages returns a sequence of every page of the search results, starting with the page of the original response.
+// The sequence stops after the last page or the first error.
+func (p *Paginator) Pages() iter.Seq2[SubmissionSearchResponse, error] {
+	return p.PagesContext(p.client.Get().Context())
+}
+
+// PagesContext is like Paginator.Pages but uses ctx for the requests.
+func (p *Paginator) PagesContext(ctx context.Context) iter.Seq2[SubmissionSearchResponse, error] {
+	return func(yield func(SubmissionSearchResponse, error) bool) {
+		seen := make(map[types.IntString]bool)
+		for _, submission := range p.first.Submissions {
+			seen[submission.SubmissionID] = true
+		}
+		if !yield(p.first, nil) {
+			return
+		}
+		pages := p.sequential
+		if p.prefetch > 1 {
+			pages = p.concurrent
+		}
+		for response, err := range pages(ctx) {
+			if err != nil {
+				yield(response, err)
+				return
+			}
+			response.Submissions = slices.DeleteFunc(response.Submissions, func(submission SubmissionSearch) bool {
+				if seen[submission.SubmissionID] {
+					return true
+				}
+				seen[submission.SubmissionID] = true
+				return false
+			})
+			if !yield(response, nil) {
+				return
+			}
+		}
+	}
+}
+
+// sequential fetches the pages following the first response one at a time.
+func (p *Paginator) sequential(ctx context.Context) iter.Seq2[SubmissionSearchResponse, error] {
+	return func(yield func(SubmissionSearchResponse, error) bool) {
+		current := p.first
+		for page := max(current.Page, 1) + 1; page <= current.PagesCount; page++ {
+			response, err := p.fetch(ctx, current, page, p.regenerate)
+			if !yield(response, err) || err != nil {
+				return
+			}
+			current = response
+		}
+	}
+}
+
+// concurrent fetches up to p.prefetch of the pages following the first response at once, and yields them in order.
+// Every request uses the latest results set, so that a regenerated RID is shared by the following pages.
+// When the results set expires, a single request regenerates it and the other requests wait for its RID.
+func (p *Paginator) concurrent(ctx context.Context) iter.Seq2[SubmissionSearchResponse, error] {
+	return func(yield func(SubmissionSearchResponse, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			response SubmissionSearchResponse
+			err      error
+		}
+		var mu sync.Mutex
+		latest := p.first
+		current := func() SubmissionSearchResponse {
+			mu.Lock()
+			defer mu.Unlock()
+			return latest
+		}
+
+		// regenerating is closed when the regeneration in progress, if any, is done.
+		var regenerating chan struct{}
+		var regenerate regenerateFunc
+		regenerate = func(ctx context.Context, previous SubmissionSearchResponse, page types.IntString, expired error) (SubmissionSearchResponse, error) {
+			mu.Lock()
+			for regenerating != nil {
+				done := regenerating
+				mu.Unlock()
+				select {
+				case <-done:
+				case <-ctx.Done():
+					return SubmissionSearchResponse{}, ctx.Err()
+				}
+				mu.Lock()
+			}
+			if renewed := latest; renewed.RID != previous.RID {
+				mu.Unlock()
+				return p.fetch(ctx, renewed, page, regenerate)
+			}
+			done := make(chan struct{})
+			regenerating = done
+			mu.Unlock()
+
+			response, err := p.regenerate(ctx, previous, page, expired)
+			mu.Lock()
+			if err == nil {
+				latest = response
+			}
+			regenerating = nil
+			mu.Unlock()
+			close(done)
+			return response, err
+		}
+
+		pending := make(map[types.IntString]chan result)
+		next := max(p.first.Page, 1) + 1
+		launched := next
+		for next <= current().PagesCount {
+			for ; launched <= current().PagesCount && launched < next+types.IntString(p.prefetch); launched++ {
+				done := make(chan result, 1)
+				pending[launched] = done
+				go func(page types.IntString) {
+					previous := current()
+					response, err := p.fetch(ctx, previous, page, regenerate)
+					if err == nil {
+						mu.Lock()
+						if latest.RID == previous.RID {
+							latest = response
+						}
+						mu.Unlock()
+					}
+					done <- result{response, err}
+				}(launched)
+			}
+
+			var r result
+			select {
+			case r = <-pending[next]:
+			case <-ctx.Done():
+				r.err = ctx.Err()
+			}
+			delete(pending, next)
+			if !yield(r.response, r.err) || r.err != nil {
+				return
+			}
+			next++
+		}
+	}
+}
+
+// Submissions returns a sequence of every submission of the search results, across all pages.
+// The sequence stops after the last submission or the first error.
+func (p *Paginator) Submissions() iter.Seq2[SubmissionSearch, error] {
+	return p.SubmissionsContext(p.client.Get().Context())
+}
+
+// SubmissionsContext is like Paginator.Submissions but uses ctx for the requests.
+func (p *Paginator) SubmissionsContext(ctx context.Context) iter.Seq2[SubmissionSearch, error] {
+	return func(yield func(SubmissionSearch, error) bool) {
+		for page, err := range p.PagesContext(ctx) {
+			if err != nil {
+				yield(SubmissionSearch{}, err)
+				return
+			}
+			for _, submission := range page.Submissions {
+				if !yield(submission, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// regenerateFunc regenerates an expired results set, see Paginator.regenerate.
+type regenerateFunc func(ctx context.Context, previous SubmissionSearchResponse, page types.IntString, expired error) (SubmissionSearchResponse, error)
+
+// fetch requests a page following the previous response, calling regenerate if its results set expired.
+func (p *Paginator) fetch(ctx context.Context, previous SubmissionSearchResponse, page types.IntString, regenerate regenerateFunc) (SubmissionSearchResponse, error) {
+	request := p.request
+	request.SID = previous.SID
+	request.Page = page
+	if previous.RID != "" {
+		if !previous.RIDExpiry.IsZero() && time.Now().After(previous.RIDExpiry) {
+			return regenerate(ctx, previous, page, fmt.Errorf("%w: %s expired at %s", ErrRIDExpired, previous.RID, previous.RIDExpiry.Format(time.RFC3339)))
+		}
+		request = SubmissionSearchRequest{
+			SID:                request.SID,
+			OutputMode:         request.OutputMode,
+			RID:                previous.RID,
+			SubmissionIDsOnly:  request.SubmissionIDsOnly,
+			SubmissionsPerPage: request.SubmissionsPerPage,
+			Page:               page,
+			KeywordsList:       request.KeywordsList,
+			NoSubmissions:      request.NoSubmissions,
+		}
+	}
+
+	response, err := p.search(ctx, request)
+	if errors.Is(err, types.ErrNoResultsFound) && previous.RID != "" {
+		return regenerate(ctx, previous, page, fmt.Errorf("%w: %w", ErrRIDExpired, err))
+	}
+	if err != nil {
+		return response, err
+	}
+	if response.RID == "" {
+		response.RID, response.RIDTTL, response.RIDTTLDuration, response.RIDExpiry = previous.RID, previous.RIDTTL, previous.RIDTTLDuration, previous.RIDExpiry
+	}
+	return response, nil
+}
+
+// regenerate runs the original search again to get a new RID, and returns the requested page of the new results set.
+// If the original search did not run in Mode 1, expired is returned instead.
+func (p *Paginator) regenerate(ctx context.Context, previous SubmissionSearchResponse, page types.IntString, expired error) (SubmissionSearchResponse, error) {
+	if p.request.RID != "" {
+		return SubmissionSearchResponse{}, expired
+	}
+	request := p.request
+	request.SID = previous.SID
+	request.GetRID = types.Yes
+	request.Page = page
+	return p.search(ctx, request)
+}
+
+func (p *Paginator) search(ctx context.Context, request SubmissionSearchRequest) (SubmissionSearchResponse, error) {
+	if p.user != nil {
+		return p.user.SearchSubmissionsContext(ctx, request)
+	}
+	return p.client.Get().SearchSubmissionsContext(ctx, request)
+}
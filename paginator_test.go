@@ -0,0 +1,59 @@
+package inkbunny_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestPagesKeepRIDExpiry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.FormValue("page"))
+		response := inkbunny.SubmissionSearchResponse{
+			SID:        r.FormValue("sid"),
+			PagesCount: 3,
+			Page:       types.IntString(max(page, 1)),
+		}
+		// Only the first page names the results set, like the API when paging with an RID.
+		if r.FormValue("rid") == "" {
+			response.RID, response.RIDTTL = "rid", "15 minutes"
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+	base, _ := url.Parse(server.URL)
+	client := inkbunny.NewClient(inkbunny.WithBaseURL(base))
+
+	response, err := client.SearchSubmissions(inkbunny.SubmissionSearchRequest{SID: "sid", GetRID: types.Yes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.RIDExpiry.IsZero() {
+		t.Fatal("got no RID expiry for the first page")
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	var pages int
+	for page, err := range response.AllPages() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		if page.RID != response.RID {
+			t.Errorf("page %d: got RID %q, want %q", page.Page, page.RID, response.RID)
+		}
+		if !page.RIDExpiry.Equal(response.RIDExpiry) {
+			t.Errorf("page %d: got RID expiry %s, want %s", page.Page, page.RIDExpiry, response.RIDExpiry)
+		}
+	}
+	if pages != 3 {
+		t.Errorf("got %d pages, want 3", pages)
+	}
+}
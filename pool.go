@@ -0,0 +1,48 @@
+package inkbunny
+
+import (
+	"context"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// PoolSubmissions returns the details of every submission of a pool, in the order set by the owner of the pool.
+// The details include the descriptions, the writing of written submissions and the pools.
+//
+// The order is the one of a search with types.OrderByPoolOrder. The LeftSubmissionID and RightSubmissionID of
+// the Pool are not followed, since they would take a request per submission.
+//
+//	submissions, err := user.PoolSubmissions(poolID)
+func (u *User) PoolSubmissions(poolID types.IntString) ([]SubmissionDetails, error) {
+	return u.PoolSubmissionsContext(u.Client().Context(), poolID)
+}
+
+// PoolSubmissionsContext is like User.PoolSubmissions but uses ctx for the requests.
+func (u *User) PoolSubmissionsContext(ctx context.Context, poolID types.IntString) ([]SubmissionDetails, error) {
+	response, err := u.SearchSubmissionsContext(ctx, SubmissionSearchRequest{
+		PoolID:             poolID,
+		OrderBy:            types.OrderByPoolOrder,
+		SubmissionsPerPage: 100,
+		GetRID:             types.Yes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for submission, err := range response.Paginate().SubmissionsContext(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, submission.SubmissionID.String())
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	details, err := u.SubmissionDetailsContext(ctx, SubmissionDetailsRequest{
+		SubmissionIDSlice: ids,
+		ShowDescription:   types.Yes,
+		ShowWriting:       types.Yes,
+		ShowPools:         types.Yes,
+	})
+	return details.Submissions, err
+}
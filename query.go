@@ -0,0 +1,418 @@
+package inkbunny
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// SearchQuery is a search parsed by ParseSearchQuery.
+//
+// The query language is a list of words, which are searched as SubmissionSearchRequest.Text, and key:value filters.
+// Values containing spaces can be quoted, and lists are separated by commas:
+//
+//	fox dragon -scraps type:comic,sketch by:artist favs:someone order:views days:30 rating:general,nudity
+//
+// The keys are:
+//   - in:keywords,title,description,md5 - the fields searched for the words. Keywords are not searched unless listed.
+//   - join:and|or|exact - how the words are joined. fieldjoin:and|or - whether the words must be in all fields.
+//   - md5:hash,... - search for files by MD5 hash. It cannot be combined with words, since the API searches the
+//     hashes as words, so they would have to match together.
+//   - keyword:id - search for a keyword ID instead of the words.
+//   - by:username and user:id - submissions owned by a user.
+//   - favs:username or favs:id - favorites of a user.
+//   - type:name,... - submission types, see SubmissionType.Name. Type IDs are also accepted.
+//   - pool:id, days:n, limit:n (count limit), page:n and per:n (submissions per page).
+//   - order:value - any types.OrderBy value, e.g. order:views.
+//   - scraps:both|no|only, or -scraps for scraps:no.
+//   - is:unread and is:random.
+//   - rating:general,nudity,mildviolence,sexual,strongviolence - ratings to enable.
+type SearchQuery struct {
+	Request SubmissionSearchRequest
+	// Ratings are the ratings enabled with rating:, or the zero value if it was not used.
+	// The API filters searches with the ratings of the session, so they must be applied with User.ChangeRatings.
+	Ratings types.Ratings
+	// FavsUsername is the username given to favs:, which must be resolved to Request.FavsUserID,
+	// e.g. with Client.SearchMembers. Numeric values set Request.FavsUserID directly.
+	FavsUsername string
+}
+
+// QueryError is a syntax error in a search query.
+type QueryError struct {
+	Offset int    // Byte offset of the token in the query.
+	Token  string // The token that could not be parsed.
+	Reason string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("search query: column %d: %q: %s", e.Offset+1, e.Token, e.Reason)
+}
+
+var submissionTypeNames = []string{
+	SubmissionTypePicturePinup:              "picture",
+	SubmissionTypeSketch:                    "sketch",
+	SubmissionTypePictureSeries:             "series",
+	SubmissionTypeComic:                     "comic",
+	SubmissionTypePortfolio:                 "portfolio",
+	SubmissionTypeShockwaveFlashAnimation:   "flash",
+	SubmissionTypeShockwaveFlashInteractive: "interactive",
+	SubmissionTypeVideoFeatureLength:        "video",
+	SubmissionTypeVideoAnimation3DCGI:       "animation",
+	SubmissionTypeMusicSingleTrack:          "music",
+	SubmissionTypeMusicAlbum:                "album",
+	SubmissionTypeWritingDocument:           "writing",
+	SubmissionTypeCharacterSheet:            "character",
+	SubmissionTypePhotography:               "photography",
+}
+
+// Name returns the name of a submission type used by the search query language, e.g. "comic".
+func (s SubmissionType) Name() string {
+	if s > 0 && int(s) < len(submissionTypeNames) {
+		return submissionTypeNames[s]
+	}
+	return strconv.Itoa(int(s))
+}
+
+var orderings = []types.OrderBy{
+	types.OrderByCreateDatetime,
+	types.OrderByLastFileUpdateDatetime,
+	types.OrderByUnreadDatetime,
+	types.OrderByUnreadDatetimeReverse,
+	types.OrderByViews,
+	types.OrderByTotalPrint,
+	types.OrderByTotalDigital,
+	types.OrderByTotalSales,
+	types.OrderByUsername,
+	types.OrderByFavDatetime,
+	types.OrderByFavStars,
+	types.OrderByFavs,
+	types.OrderByPoolOrder,
+}
+
+type token struct {
+	offset int
+	key    string // empty for words
+	value  string
+	raw    string
+}
+
+// ParseSearchQuery parses a search query into a SearchQuery. See SearchQuery for the syntax.
+// Errors are reported as a *QueryError.
+func ParseSearchQuery(query string) (SearchQuery, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return SearchQuery{}, err
+	}
+
+	var q SearchQuery
+	r := &q.Request
+	var words, hashes []string
+	var md5 *token
+	for _, t := range tokens {
+		fail := func(format string, args ...any) error {
+			return &QueryError{Offset: t.offset, Token: t.raw, Reason: fmt.Sprintf(format, args...)}
+		}
+		number := func() (types.IntString, error) {
+			n, err := strconv.Atoi(t.value)
+			if err != nil || n <= 0 {
+				return 0, fail("%s must be a positive number", t.key)
+			}
+			return types.IntString(n), nil
+		}
+		list := strings.Split(t.value, ",")
+
+		switch t.key {
+		case "":
+			if t.raw == "-scraps" {
+				r.Scraps = ScrapsNo
+				continue
+			}
+			if strings.HasPrefix(t.raw, "-") {
+				return SearchQuery{}, fail("excluding words is not supported")
+			}
+			words = append(words, t.value)
+		case "in":
+			r.Keywords = types.Address(types.No)
+			for _, field := range list {
+				switch field {
+				case "keywords":
+					r.Keywords = types.Address(types.Yes)
+				case "title":
+					r.Title = types.Address(types.Yes)
+				case "description":
+					r.Description = types.Address(types.Yes)
+				case "md5":
+					r.MD5 = types.Address(types.Yes)
+				default:
+					return SearchQuery{}, fail("unknown field %q, must be keywords, title, description or md5", field)
+				}
+			}
+		case "join":
+			if !slices.Contains([]types.JoinType{types.JoinTypeAnd, types.JoinTypeOr, types.JoinTypeExact}, t.value) {
+				return SearchQuery{}, fail("must be and, or or exact")
+			}
+			r.StringJoinType = t.value
+		case "fieldjoin":
+			if !slices.Contains([]types.FieldJoinType{types.FieldJoinTypeAnd, types.FieldJoinTypeOr}, t.value) {
+				return SearchQuery{}, fail("must be and or or")
+			}
+			r.FieldJoinType = t.value
+		case "md5":
+			md5 = &t
+			hashes = append(hashes, list...)
+		case "keyword":
+			if r.KeywordID, err = number(); err != nil {
+				return SearchQuery{}, err
+			}
+		case "by":
+			r.Username = t.value
+		case "user":
+			if r.UserID, err = number(); err != nil {
+				return SearchQuery{}, err
+			}
+		case "favs":
+			if id, err := strconv.Atoi(t.value); err == nil {
+				r.FavsUserID = types.IntString(id)
+			} else {
+				q.FavsUsername = t.value
+			}
+		case "type":
+			for _, name := range list {
+				i := slices.Index(submissionTypeNames, name)
+				if n, err := strconv.Atoi(name); err == nil {
+					i = n
+				}
+				if i <= 0 || i >= len(submissionTypeNames) {
+					return SearchQuery{}, fail("unknown submission type %q", name)
+				}
+				r.Type = append(r.Type, SubmissionType(i))
+			}
+		case "pool":
+			if r.PoolID, err = number(); err != nil {
+				return SearchQuery{}, err
+			}
+		case "days":
+			if r.DaysLimit, err = number(); err != nil {
+				return SearchQuery{}, err
+			}
+		case "limit":
+			if r.CountLimit, err = number(); err != nil {
+				return SearchQuery{}, err
+			}
+		case "page":
+			if r.Page, err = number(); err != nil {
+				return SearchQuery{}, err
+			}
+		case "per":
+			if r.SubmissionsPerPage, err = number(); err != nil {
+				return SearchQuery{}, err
+			}
+		case "order":
+			if !slices.Contains(orderings, t.value) {
+				return SearchQuery{}, fail("unknown order %q", t.value)
+			}
+			r.OrderBy = t.value
+		case "scraps":
+			if !slices.Contains([]Scraps{ScrapsBoth, ScrapsNo, ScrapsOnly}, t.value) {
+				return SearchQuery{}, fail("must be both, no or only")
+			}
+			r.Scraps = t.value
+		case "is":
+			switch t.value {
+			case "unread":
+				r.UnreadSubmissions = types.Yes
+			case "random":
+				r.Random = types.Yes
+			default:
+				return SearchQuery{}, fail("must be unread or random")
+			}
+		case "rating":
+			ratings, err := types.ParseRatings(t.value)
+			if err != nil {
+				return SearchQuery{}, fail("%v", err)
+			}
+			q.Ratings = ratings
+		default:
+			return SearchQuery{}, fail("unknown key %q", t.key)
+		}
+	}
+
+	if md5 != nil {
+		if len(words) > 0 {
+			return SearchQuery{}, &QueryError{Offset: md5.offset, Token: md5.raw, Reason: "md5 cannot be combined with words"}
+		}
+		words = hashes
+		r.MD5 = types.Address(types.Yes)
+		if r.Keywords == nil {
+			r.Keywords = types.Address(types.No)
+		}
+	}
+	r.Text = strings.Join(words, " ")
+	return q, nil
+}
+
+// tokenize splits a query on spaces, keeping quoted values together.
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(query); {
+		if query[i] == ' ' || query[i] == '\t' || query[i] == '\n' {
+			i++
+			continue
+		}
+		t := token{offset: i}
+		var value strings.Builder
+		quoted := false
+		for ; i < len(query); i++ {
+			c := query[i]
+			if c == '"' {
+				end := strings.IndexByte(query[i+1:], '"')
+				if end < 0 {
+					return nil, &QueryError{Offset: i, Token: query[t.offset:], Reason: "unterminated quote"}
+				}
+				value.WriteString(query[i+1 : i+1+end])
+				i += end + 1
+				quoted = true
+				continue
+			}
+			if c == ' ' || c == '\t' || c == '\n' {
+				break
+			}
+			if c == ':' && t.key == "" && !quoted && value.Len() > 0 {
+				t.key = strings.ToLower(value.String())
+				value.Reset()
+				continue
+			}
+			value.WriteByte(c)
+		}
+		t.raw = query[t.offset:i]
+		t.value = value.String()
+		if t.key != "" && t.key != "by" && t.key != "favs" {
+			t.value = strings.ToLower(t.value)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// String returns the query, which is parsed back into the same SearchQuery by ParseSearchQuery.
+func (q SearchQuery) String() string {
+	query := q.Request.String()
+	var extra []string
+	if q.FavsUsername != "" && q.Request.FavsUserID == 0 {
+		extra = append(extra, "favs:"+quote(q.FavsUsername))
+	}
+	if q.Ratings.IsSet() {
+		extra = append(extra, "rating:"+strings.Join(q.Ratings.Names(), ","))
+	}
+	return strings.Join(append(strings.Fields(query), extra...), " ")
+}
+
+// String returns the request in the search query language of ParseSearchQuery.
+// The session, RID and output options are not part of the language and are left out.
+func (r SubmissionSearchRequest) String() string {
+	var parts []string
+	add := func(key, value string) {
+		parts = append(parts, key+":"+value)
+	}
+
+	onlyMD5 := isYes(r.MD5) && r.Keywords != nil && !bool(*r.Keywords) && !isYes(r.Title) && !isYes(r.Description)
+	if onlyMD5 {
+		add("md5", strings.Join(strings.Fields(r.Text), ","))
+	} else {
+		for _, word := range strings.Fields(r.Text) {
+			parts = append(parts, quoteWord(word))
+		}
+		if r.Keywords != nil && !bool(*r.Keywords) || isYes(r.Title) || isYes(r.Description) || isYes(r.MD5) {
+			var fields []string
+			if r.Keywords == nil || bool(*r.Keywords) {
+				fields = append(fields, "keywords")
+			}
+			for _, f := range []struct {
+				name string
+				on   *types.BooleanYN
+			}{{"title", r.Title}, {"description", r.Description}, {"md5", r.MD5}} {
+				if isYes(f.on) {
+					fields = append(fields, f.name)
+				}
+			}
+			add("in", strings.Join(fields, ","))
+		}
+	}
+	if r.StringJoinType != "" {
+		add("join", r.StringJoinType)
+	}
+	if r.FieldJoinType != "" {
+		add("fieldjoin", r.FieldJoinType)
+	}
+	if r.KeywordID != 0 {
+		add("keyword", r.KeywordID.String())
+	}
+	if r.Username != "" {
+		add("by", quote(r.Username))
+	}
+	if r.UserID != 0 {
+		add("user", r.UserID.String())
+	}
+	if r.FavsUserID != 0 {
+		add("favs", r.FavsUserID.String())
+	}
+	if len(r.Type) > 0 {
+		names := make([]string, len(r.Type))
+		for i, t := range r.Type {
+			names[i] = t.Name()
+		}
+		add("type", strings.Join(names, ","))
+	}
+	if r.PoolID != 0 {
+		add("pool", r.PoolID.String())
+	}
+	switch r.Scraps {
+	case "":
+	case ScrapsNo:
+		parts = append(parts, "-scraps")
+	default:
+		add("scraps", r.Scraps)
+	}
+	if r.OrderBy != "" {
+		add("order", r.OrderBy)
+	}
+	if r.DaysLimit != 0 {
+		add("days", r.DaysLimit.String())
+	}
+	if r.CountLimit != 0 {
+		add("limit", r.CountLimit.String())
+	}
+	if r.UnreadSubmissions {
+		add("is", "unread")
+	}
+	if r.Random {
+		add("is", "random")
+	}
+	if r.Page != 0 {
+		add("page", r.Page.String())
+	}
+	if r.SubmissionsPerPage != 0 {
+		add("per", r.SubmissionsPerPage.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteWord quotes a word that would otherwise be parsed as a key or an exclusion.
+func quoteWord(word string) string {
+	if strings.Contains(word, ":") || strings.HasPrefix(word, "-") {
+		return `"` + word + `"`
+	}
+	return quote(word)
+}
+
+// quote quotes a value containing spaces.
+func quote(value string) string {
+	if strings.ContainsFunc(value, func(r rune) bool { return unicode.IsSpace(r) }) {
+		return `"` + value + `"`
+	}
+	return value
+}
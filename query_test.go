@@ -0,0 +1,73 @@
+package inkbunny_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	const query = "fox dragon -scraps type:comic,sketch by:artist favs:someone order:views days:30 rating:general,nudity"
+	q, err := inkbunny.ParseSearchQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := q.Request
+	if r.Text != "fox dragon" {
+		t.Errorf("Text = %q, want %q", r.Text, "fox dragon")
+	}
+	if r.MD5 != nil || r.Keywords != nil {
+		t.Errorf("fields: keywords %v, md5 %v, want the defaults", r.Keywords, r.MD5)
+	}
+	if r.Scraps != inkbunny.ScrapsNo {
+		t.Errorf("Scraps = %q, want %q", r.Scraps, inkbunny.ScrapsNo)
+	}
+	if want := []inkbunny.SubmissionType{inkbunny.SubmissionTypeComic, inkbunny.SubmissionTypeSketch}; !slices.Equal(r.Type, want) {
+		t.Errorf("Type = %v, want %v", r.Type, want)
+	}
+	if r.Username != "artist" || q.FavsUsername != "someone" {
+		t.Errorf("Username = %q, FavsUsername = %q", r.Username, q.FavsUsername)
+	}
+	if r.OrderBy != types.OrderByViews || r.DaysLimit != 30 {
+		t.Errorf("OrderBy = %q, DaysLimit = %d", r.OrderBy, r.DaysLimit)
+	}
+	if !q.Ratings.IsSet() {
+		t.Error("ratings were not set")
+	}
+
+	again, err := inkbunny.ParseSearchQuery(q.String())
+	if err != nil {
+		t.Fatalf("parsing %q: %v", q.String(), err)
+	}
+	if again.String() != q.String() {
+		t.Errorf("round trip: got %q, want %q", again.String(), q.String())
+	}
+}
+
+func TestParseSearchQueryMD5(t *testing.T) {
+	q, err := inkbunny.ParseSearchQuery("md5:abc,def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := q.Request
+	if r.Text != "abc def" || r.MD5 == nil || !bool(*r.MD5) || r.Keywords == nil || bool(*r.Keywords) {
+		t.Errorf("got %+v, want only the hashes searched by MD5", r)
+	}
+	if got := q.String(); got != "md5:abc,def" {
+		t.Errorf("String() = %q, want %q", got, "md5:abc,def")
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"-fox", "in:tags", "days:x", "days:0", "page:-1", `by:"artist`, "order:name", "type:unknown",
+		"fox md5:abc", "md5:abc fox",
+	} {
+		_, err := inkbunny.ParseSearchQuery(query)
+		if _, ok := err.(*inkbunny.QueryError); !ok {
+			t.Errorf("%q: got %v, want a *QueryError", query, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package inkbunny
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+)
+
+// Limiter blocks until a request is allowed to be sent or ctx is done.
+// *rate.Limiter from golang.org/x/time/rate also satisfies this interface.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a Limiter that allows bursts of up to burst requests, refilling perSecond tokens every second.
+// It is safe for concurrent use.
+type TokenBucket struct {
+	mu        sync.Mutex
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+// NewTokenBucket returns a full TokenBucket.
+// A perSecond of zero or less, or NaN, is treated as unlimited, like an infinite rate, and Wait never blocks.
+//
+//	limiter := NewTokenBucket(1, 5) // 1 request per second, with bursts of up to 5
+func NewTokenBucket(perSecond float64, burst int) *TokenBucket {
+	burst = max(burst, 1)
+	if !(perSecond > 0) {
+		perSecond = math.Inf(1)
+	}
+	return &TokenBucket{
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      time.Now(),
+	}
+}
+
+// Every returns a TokenBucket that allows one request per interval, with bursts of up to burst requests.
+// An interval of zero or less is unlimited.
+//
+//	limiter := Every(time.Minute, 10) // 10 uploads per minute
+func Every(interval time.Duration, burst int) *TokenBucket {
+	return NewTokenBucket(float64(time.Second)/float64(interval), burst)
+}
+
+// Wait takes a token from the bucket, blocking until one is available or ctx is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if math.IsInf(b.perSecond, 1) {
+		return ctx.Err()
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.perSecond)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.perSecond * float64(time.Second))
+		b.mu.Unlock()
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// WithRateLimit sets a Limiter shared by every request made through the Client, including uploads and from all Users.
+//
+//	client := NewClient(WithRateLimit(NewTokenBucket(2, 5)))
+func WithRateLimit(limiter Limiter) func(*Client) {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// WithEndpointRateLimit sets an additional Limiter for a single API endpoint.
+// endpoint is the name used by ApiUrl, e.g. "upload" for api_upload.php.
+// Requests to the endpoint wait for both the Client Limiter and this one.
+//
+//	client := NewClient(
+//		WithRateLimit(NewTokenBucket(2, 5)),
+//		WithEndpointRateLimit("upload", Every(time.Minute, 1)),
+//	)
+func WithEndpointRateLimit(endpoint string, limiter Limiter) func(*Client) {
+	return func(c *Client) {
+		c.SetEndpointRateLimit(endpoint, limiter)
+	}
+}
+
+func (c *Client) SetRateLimit(limiter Limiter) {
+	c.limiter = limiter
+}
+
+// SetEndpointRateLimit is like WithEndpointRateLimit. Passing a nil limiter removes the endpoint Limiter.
+func (c *Client) SetEndpointRateLimit(endpoint string, limiter Limiter) {
+	if c.endpointLimiters == nil {
+		c.endpointLimiters = make(map[string]Limiter)
+	}
+	if limiter == nil {
+		delete(c.endpointLimiters, apiPath(endpoint))
+		return
+	}
+	c.endpointLimiters[apiPath(endpoint)] = limiter
+}
+
+// wait blocks until the Client and endpoint Limiters allow a request to u.
+func (c *Client) wait(ctx context.Context, u *url.URL) error {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if limiter, ok := c.endpointLimiters[path.Base(u.Path)]; ok {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package inkbunny_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := inkbunny.NewTokenBucket(100, 2)
+	start := time.Now()
+	for range 4 {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("4 requests with a burst of 2 at 100/s took %s, want at least 20ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := inkbunny.NewTokenBucket(1, 1).Wait(ctx); err != context.Canceled {
+		t.Errorf("cancelled: got %v, want context.Canceled", err)
+	}
+}
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	for _, bucket := range []*inkbunny.TokenBucket{
+		inkbunny.NewTokenBucket(0, 1),
+		inkbunny.NewTokenBucket(-1, 1),
+		inkbunny.NewTokenBucket(math.NaN(), 1),
+		inkbunny.NewTokenBucket(math.Inf(1), 1),
+		inkbunny.Every(0, 1),
+		inkbunny.Every(-time.Second, 1),
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		for range 100 {
+			if err := bucket.Wait(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,131 @@
+package inkbunny
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"path"
+	"syscall"
+	"time"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// RetryPolicy controls how Client.PostForm and PostDecode retry failed requests.
+// The zero value disables retries, which is the default for NewClient.
+//
+// Uploads are never retried as their files are streamed. Other non-idempotent endpoints
+// such as api_delsubmission.php and api_delfile.php are only retried when RetryNonIdempotent is set.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. Each following retry multiplies it by Multiplier.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts. Zero means no cap.
+	MaxDelay time.Duration
+	// Multiplier is the growth factor of the delay between attempts. Defaults to 2.
+	Multiplier float64
+	// Jitter is the fraction of the delay, from 0 to 1, that is randomly removed to spread out retries.
+	Jitter float64
+	// Retryable classifies whether an error is worth retrying. Defaults to IsRetryable.
+	Retryable func(err error) bool
+	// RetryNonIdempotent allows retrying endpoints that change state when sent twice, see NonIdempotent.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy retries up to 3 times with an exponential backoff starting at 500ms.
+//
+//	client := NewClient(WithRetry(DefaultRetryPolicy))
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	Multiplier:  2,
+	Jitter:      0.2,
+}
+
+// NonIdempotent lists the API endpoints that are not retried unless RetryPolicy.RetryNonIdempotent is set.
+var NonIdempotent = map[string]bool{
+	"api_upload.php":        true,
+	"api_delsubmission.php": true,
+	"api_delfile.php":       true,
+}
+
+// WithRetry sets the RetryPolicy used by Client.PostForm and PostDecode.
+func WithRetry(policy RetryPolicy) func(*Client) {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+func (c *Client) SetRetry(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// Backoff returns the delay before the given retry, starting at 1 for the first retry.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	delay := float64(p.BaseDelay) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// shouldRetry reports whether the request to u should be attempted again after err.
+func (p RetryPolicy) shouldRetry(u *url.URL, attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if !p.RetryNonIdempotent && NonIdempotent[path.Base(u.Path)] {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// IsRetryable is the default classifier of RetryPolicy.
+// It retries temporary API errors (see types.APIError.Temporary), timeouts and dropped connections,
+// but never context cancellation or errors such as types.ErrInvalidLogin.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *types.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -2,6 +2,7 @@ package inkbunny
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"iter"
 	"regexp"
@@ -192,6 +193,8 @@ type SubmissionSearchResponse struct {
 	KeywordList          []KeywordList      `json:"keyword_list,omitempty"`
 	Submissions          []SubmissionSearch `json:"submissions,omitempty"`
 	client               *Client
+	user                 *User
+	request              SubmissionSearchRequest
 }
 
 type KeywordList struct {
@@ -202,7 +205,7 @@ type KeywordList struct {
 
 type SubmissionSearch struct {
 	SubmissionBasic
-	UnreadDateSystem string          `json:"unread_datetime_system,omitempty"`
+	UnreadDateSystem types.Time      `json:"unread_datetime_system,omitzero"`
 	UnreadDateUser   string          `json:"unread_datetime,omitempty"`
 	Updated          types.BooleanYN `json:"updated,omitempty"`
 	Stars            types.IntString `json:"stars,omitempty"`
@@ -276,85 +279,85 @@ func (s *SubmissionTypes) UnmarshalJSON(data []byte) error {
 }
 
 // AllPages returns a sequence of all the pages in a submission search response, repeatedly calling Client.SearchSubmissions.
-// Make sure you set SubmissionSearchRequest.GetRID to types.Yes prior or the other pages might not have the correct results.
-// Additionally, one should also check SubmissionSearchResponse.RIDTTLDuration or SubmissionSearchResponse.RIDExpiry.
+// Make sure you set SubmissionSearchRequest.GetRID to types.Yes prior or the other pages are fetched by running the search again.
+// If the RID expires before all pages were fetched, the sequence stops with ErrRIDExpired.
+// See Paginator for more details.
 func (s SubmissionSearchResponse) AllPages() iter.Seq2[SubmissionSearchResponse, error] {
-	return func(yield func(SubmissionSearchResponse, error) bool) {
-		for i := range s.PagesCount.Iter() {
-			if i == 0 {
-				if !yield(s, nil) {
-					return
-				}
-				continue
-			}
-			request := SubmissionSearchRequest{
-				SID:  s.SID,
-				RID:  s.RID,
-				Page: i,
-			}
-			if !yield(s.client.Get().SearchSubmissions(request)) {
-				return
-			}
-		}
-	}
+	return s.Paginate().Pages()
 }
 
 // AllSubmissions returns a sequence of all submission lists across all pages of the search results, repeatedly calling Client.SearchSubmissions.
-// Make sure you set SubmissionSearchRequest.GetRID to types.Yes prior or the other pages might not have the correct results.
-// Additionally, one should also check SubmissionSearchResponse.RIDTTLDuration or SubmissionSearchResponse.RIDExpiry.
+// Make sure you set SubmissionSearchRequest.GetRID to types.Yes prior or the other pages are fetched by running the search again.
+// If the RID expires before all pages were fetched, the sequence stops with ErrRIDExpired.
+// Use Paginator.Submissions to iterate over each submission instead.
 func (s SubmissionSearchResponse) AllSubmissions() iter.Seq2[[]SubmissionSearch, error] {
 	return func(yield func([]SubmissionSearch, error) bool) {
-		for i := range s.PagesCount.Iter() {
-			if i == 0 {
-				if !yield(s.Submissions, nil) {
-					return
-				}
-				continue
-			}
-			request := SubmissionSearchRequest{
-				SID:  s.SID,
-				RID:  s.RID,
-				Page: i + 1,
-			}
-			response, err := s.client.Get().SearchSubmissions(request)
-			if !yield(response.Submissions, err) {
+		for page, err := range s.Paginate().Pages() {
+			if !yield(page.Submissions, err) || err != nil {
 				return
 			}
 		}
 	}
 }
 
-// Details returns the SubmissionDetails of the current page
+// Details returns the SubmissionDetails of the current page.
+// Pages with more than MaxSubmissionIDs submissions are requested in chunks.
 func (s SubmissionSearchResponse) Details() (SubmissionDetailsResponse, error) {
+	return s.DetailsContext(s.client.Get().Context())
+}
+
+// DetailsContext is like SubmissionSearchResponse.Details but uses ctx for the requests.
+func (s SubmissionSearchResponse) DetailsContext(ctx context.Context) (SubmissionDetailsResponse, error) {
 	ids := make([]string, len(s.Submissions))
 	for i, v := range s.Submissions {
 		ids[i] = v.SubmissionID.String()
 	}
-	return s.client.Get().SubmissionDetails(SubmissionDetailsRequest{
+	req := SubmissionDetailsRequest{
 		SID:               s.SID,
 		SubmissionIDSlice: ids,
-	})
+	}
+	if s.user != nil {
+		return s.user.SubmissionDetailsContext(ctx, req)
+	}
+	return s.client.Get().SubmissionDetailsContext(ctx, req)
 }
 
 func (u *User) SearchSubmissions(req SubmissionSearchRequest) (SubmissionSearchResponse, error) {
+	return u.SearchSubmissionsContext(u.Client().Context(), req)
+}
+
+// SearchSubmissionsContext is like User.SearchSubmissions but uses ctx for the request.
+func (u *User) SearchSubmissionsContext(ctx context.Context, req SubmissionSearchRequest) (SubmissionSearchResponse, error) {
 	if req.SID == "" {
-		if u.SID == "" {
+		if u.sid() == "" {
 			return SubmissionSearchResponse{}, ErrNotLoggedIn
 		}
-		req.SID = u.SID
+		req.SID = u.sid()
 	}
 
-	return u.Client().SearchSubmissions(req)
+	response, err := withSession(ctx, u, req.SID, func(sid string) (SubmissionSearchResponse, error) {
+		req.SID = sid
+		return u.Client().SearchSubmissionsContext(ctx, req)
+	})
+	response.user = u
+	return response, err
 }
 
 func (c *Client) SearchSubmissions(req SubmissionSearchRequest) (SubmissionSearchResponse, error) {
+	return c.SearchSubmissionsContext(c.Context(), req)
+}
+
+// SearchSubmissionsContext is like Client.SearchSubmissions but uses ctx for the request.
+func (c *Client) SearchSubmissionsContext(ctx context.Context, req SubmissionSearchRequest) (SubmissionSearchResponse, error) {
 	if req.SID == "" {
 		return SubmissionSearchResponse{}, ErrEmptySID
 	}
-	response, err := PostDecode[SubmissionSearchResponse](c, ApiUrl("search"), req)
+	response, err := PostDecodeContext[SubmissionSearchResponse](ctx, c, c.ApiUrl("search"), req)
 	if err != nil {
 		return response, err
 	}
+	response.client = c
+	response.request = req
 
 	if response.RIDTTL != "" {
 		response.RIDTTLDuration = TTLToDuration(response.RIDTTL)
@@ -368,6 +371,11 @@ func SearchSubmissions(req SubmissionSearchRequest) (SubmissionSearchResponse, e
 	return DefaultClient.SearchSubmissions(req)
 }
 
+// SearchSubmissionsContext is like SearchSubmissions but uses ctx for the request.
+func SearchSubmissionsContext(ctx context.Context, req SubmissionSearchRequest) (SubmissionSearchResponse, error) {
+	return DefaultClient.SearchSubmissionsContext(ctx, req)
+}
+
 var shortDuration = regexp.MustCompile(`\d+[smhdwy]`)
 
 func TTLToDuration(ttl string) time.Duration {
@@ -0,0 +1,191 @@
+package inkbunny
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// Credentials returns the username and password used to log in again once a session expires.
+// It is called every time a User needs to re-authenticate, so it can fetch fresh secrets from a vault or the environment.
+type Credentials func() (username, password string, err error)
+
+// StaticCredentials returns Credentials that always log in with the given username and password.
+func StaticCredentials(username, password string) Credentials {
+	return func() (string, string, error) {
+		return username, password, nil
+	}
+}
+
+var ErrNoCredentials = errors.New("no credentials to log in again")
+
+// WithCredentials enables automatic re-login for every User logged in through this Client.
+// When a request fails with types.ErrInvalidSessionID, the User logs in again using creds,
+// restores its Ratings and replays the failed request once.
+//
+//	client := NewClient(WithCredentials(StaticCredentials("username", "password")))
+//	user, err := client.Login("username", "password")
+func WithCredentials(creds Credentials) func(*Client) {
+	return func(c *Client) {
+		c.credentials = creds
+	}
+}
+
+// session holds the re-login state of a User. It is shared between copies of the same User.
+type session struct {
+	mu          sync.Mutex
+	credentials Credentials
+}
+
+// SetCredentials enables automatic re-login for this User, overriding the Credentials set by WithCredentials.
+// Passing nil disables automatic re-login for this User.
+func (u *User) SetCredentials(creds Credentials) {
+	if u.session == nil {
+		u.session = new(session)
+	}
+	u.session.credentials = creds
+}
+
+// locked calls fn while holding the session lock, under which relogin replaces the SID and Ratings of the User.
+func (u *User) locked(fn func()) {
+	if u.session == nil {
+		fn()
+		return
+	}
+	u.session.mu.Lock()
+	defer u.session.mu.Unlock()
+	fn()
+}
+
+// sid returns the SID of the User, read under the session lock.
+func (u *User) sid() string {
+	var sid string
+	u.locked(func() { sid = u.SID })
+	return sid
+}
+
+func (u *User) credentials() Credentials {
+	if u.session != nil {
+		return u.session.credentials
+	}
+	return nil
+}
+
+// Relogin logs in again using the User's Credentials, updating the SID and restoring the previous Ratings.
+func (u *User) Relogin() error {
+	if u == nil {
+		return ErrNilUser
+	}
+	return u.relogin(u.Client().Context(), u.sid())
+}
+
+// ReloginContext is like User.Relogin but uses ctx for the requests.
+func (u *User) ReloginContext(ctx context.Context) error {
+	if u == nil {
+		return ErrNilUser
+	}
+	return u.relogin(ctx, u.sid())
+}
+
+// relogin logs in again unless another caller already replaced the expired SID.
+func (u *User) relogin(ctx context.Context, expired string) error {
+	creds := u.credentials()
+	if creds == nil {
+		return ErrNoCredentials
+	}
+	u.session.mu.Lock()
+	defer u.session.mu.Unlock()
+	if u.SID != expired {
+		return nil
+	}
+
+	username, password, err := creds()
+	if err != nil {
+		return fmt.Errorf("error getting credentials: %w", err)
+	}
+	fresh, err := u.Client().LoginContext(ctx, username, password)
+	if err != nil {
+		return err
+	}
+
+	ratings := u.Ratings
+	u.SID = fresh.SID
+	u.Username = fresh.Username
+	u.UserID = fresh.UserID
+	u.Ratings = fresh.Ratings
+	if hasRatings(ratings) && ratings.String() != fresh.Ratings.String() {
+		restored, err := u.changeRatings(ctx, fresh.SID, ratings)
+		if err != nil {
+			return fmt.Errorf("error restoring ratings: %w", err)
+		}
+		u.Ratings = restored
+	}
+	return nil
+}
+
+func hasRatings(r types.Ratings) bool {
+	return r.General != nil || r.Nudity != nil || r.MildViolence != nil || r.Sexual != nil || r.StrongViolence != nil
+}
+
+// withSession calls fn with sid. If the session expired and the User has Credentials,
+// it logs in again and replays fn once with the new SID.
+// Requests made with a SID other than the User's own at the time of the request are never replayed.
+func withSession[T any](ctx context.Context, u *User, sid string, fn func(sid string) (T, error)) (T, error) {
+	own := sid == u.sid()
+	result, err := fn(sid)
+	if err == nil || !own || u.credentials() == nil || !errors.Is(err, types.ErrInvalidSessionID) {
+		return result, err
+	}
+	if err := u.relogin(ctx, sid); err != nil {
+		return result, fmt.Errorf("session expired and could not log in again: %w", err)
+	}
+	return fn(u.sid())
+}
+
+// replayable reports whether the UploadRequest uploads a single file or a single zip file, which is sent in a single
+// request, and all of its files can be rewound, which makes it safe to send again after a failed attempt.
+// Uploads of several files are never replayed, since a failed attempt may already have created a submission.
+func (r UploadRequest) replayable() bool {
+	zipOnly := r.ZipFile != nil && len(r.Files) == 0
+	single := r.ZipFile == nil && len(r.Files) == 1
+	if !zipOnly && !single {
+		return false
+	}
+	for _, f := range r.contents() {
+		if _, ok := f.File.(io.Seeker); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// rewind seeks all files back to the start. It should only be called after replayable returns true.
+func (r UploadRequest) rewind() error {
+	for _, f := range r.contents() {
+		if _, err := f.File.(io.Seeker).Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contents returns every non-nil FileContent of the UploadRequest.
+func (r UploadRequest) contents() []*FileContent {
+	var files []*FileContent
+	if r.ZipFile != nil {
+		files = append(files, r.ZipFile)
+	}
+	for _, f := range r.Files {
+		if f.MainFile != nil {
+			files = append(files, f.MainFile)
+		}
+		if f.Thumbnail != nil {
+			files = append(files, f.Thumbnail)
+		}
+	}
+	return files
+}
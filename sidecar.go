@@ -0,0 +1,265 @@
+package inkbunny
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// Sidecar writes the metadata of a File of a submission into a file next to it,
+// so that the metadata travels with downloaded files.
+//
+//	err := inkbunny.WriteSidecars("downloads/123456_01.png", submission, submission.Files[0],
+//		inkbunny.JSONSidecar{}, inkbunny.XMPSidecar{})
+//
+// Use Downloader.Sidecars to write them while downloading.
+type Sidecar interface {
+	// Path returns the path of the sidecar of the file saved at name.
+	Path(name string) string
+	// Write writes the metadata of the file of the submission.
+	Write(w io.Writer, submission SubmissionDetails, file File) error
+}
+
+// WriteSidecars writes the sidecars of the file saved at name. Each sidecar is first written to a ".tmp" file
+// and renamed once complete. The errors of every sidecar are joined with errors.Join.
+func WriteSidecars(name string, submission SubmissionDetails, file File, sidecars ...Sidecar) error {
+	var errs []error
+	for _, sidecar := range sidecars {
+		if err := writeSidecar(sidecar, name, submission, file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func writeSidecar(sidecar Sidecar, name string, submission SubmissionDetails, file File) error {
+	target := sidecar.Path(name)
+	temp := target + ".tmp"
+	out, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+	err = sidecar.Write(out, submission, file)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("error writing sidecar %s: %w", target, err)
+	}
+	return os.Rename(temp, target)
+}
+
+// JSONSidecar writes the SubmissionDetails as JSON, with the File it accompanies under "file".
+// The sidecar can be decoded back into a SubmissionDetails. It is saved as "name.inkbunny.json".
+type JSONSidecar struct{}
+
+func (JSONSidecar) Path(name string) string {
+	return name + ".inkbunny.json"
+}
+
+func (JSONSidecar) Write(w io.Writer, submission SubmissionDetails, file File) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		SubmissionDetails
+		File File `json:"file"`
+	}{submission, file})
+}
+
+// GalleryDLSidecar writes the metadata in the format of the inkbunny extractor of gallery-dl,
+// so that archives can be read by tools that support it. It is saved as "name.json", like gallery-dl does.
+//
+// The fields of the submission and the file are merged, with keywords as "tags", rating names as "ratings",
+// the position of the file as "num" starting at 1, and "date", "filename" and "extension" of the file.
+// The date falls back to the date of the submission, and is left out when neither is known.
+type GalleryDLSidecar struct{}
+
+// galleryDLBooleans are the fields that gallery-dl converts to booleans.
+var galleryDLBooleans = []string{"deleted", "favorite", "friends_only", "guest_block", "hidden", "public", "scraps"}
+
+func (GalleryDLSidecar) Path(name string) string {
+	return name + ".json"
+}
+
+func (GalleryDLSidecar) Write(w io.Writer, submission SubmissionDetails, file File) error {
+	metadata, err := toMap(submission)
+	if err != nil {
+		return err
+	}
+	fileMetadata, err := toMap(file)
+	if err != nil {
+		return err
+	}
+	delete(metadata, "keywords")
+	delete(metadata, "files")
+	tags := make([]string, len(submission.Keywords))
+	for i, keyword := range submission.Keywords {
+		tags[i] = keyword.KeywordName
+	}
+	ratings := make([]string, len(submission.Ratings))
+	for i, rating := range submission.Ratings {
+		ratings[i] = rating.Name
+	}
+	metadata["tags"] = tags
+	metadata["ratings"] = ratings
+	for _, key := range galleryDLBooleans {
+		if value, ok := metadata[key]; ok {
+			metadata[key] = value == types.Yes.String()
+		}
+	}
+	for key, value := range fileMetadata {
+		metadata[key] = value
+	}
+	extension := path.Ext(file.FileName)
+	metadata["deleted"] = bool(file.Deleted)
+	date := file.CreateDateTime
+	if date.IsZero() {
+		date = submission.CreateDateSystem
+	}
+	if !date.IsZero() {
+		metadata["date"] = date.UTC().Format(time.DateTime)
+	} else {
+		delete(metadata, "date")
+	}
+	metadata["filename"] = strings.TrimSuffix(file.FileName, extension)
+	metadata["extension"] = strings.TrimPrefix(extension, ".")
+	metadata["num"] = int(file.SubmissionFileOrder) + 1
+	metadata["category"] = "inkbunny"
+	metadata["subcategory"] = "post"
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(metadata)
+}
+
+func toMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	return m, json.Unmarshal(data, &m)
+}
+
+// XMPSidecar writes an XMP sidecar that can be read by digital asset management software. It is saved as "name.xmp".
+//
+// It contains the title, description, artist, keywords and creation date in the Dublin Core and XMP namespaces,
+// the address of the page of the file as dc:source, and the IDs and rating of the submission in the inkbunny namespace.
+type XMPSidecar struct{}
+
+// XMPNamespace is the namespace of the Inkbunny specific properties of an XMPSidecar.
+const XMPNamespace = "https://inkbunny.net/xmp/1.0/"
+
+func (XMPSidecar) Path(name string) string {
+	return name + ".xmp"
+}
+
+func (XMPSidecar) Write(w io.Writer, submission SubmissionDetails, file File) error {
+	x := &xmpWriter{}
+	x.raw(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	x.raw(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	x.raw(` <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
+	x.raw(`  <rdf:Description rdf:about=""` + "\n")
+	x.raw(`    xmlns:dc="http://purl.org/dc/elements/1.1/"` + "\n")
+	x.raw(`    xmlns:xmp="http://ns.adobe.com/xap/1.0/"` + "\n")
+	x.raw(`    xmlns:inkbunny="` + XMPNamespace + `">` + "\n")
+
+	x.alt("dc:title", submission.Title)
+	x.list("dc:creator", "rdf:Seq", submission.Username)
+	x.alt("dc:description", submission.Description)
+	keywords := make([]string, len(submission.Keywords))
+	for i, keyword := range submission.Keywords {
+		keywords[i] = keyword.KeywordName
+	}
+	x.list("dc:subject", "rdf:Bag", keywords...)
+	x.property("dc:source", pageURL(submission, file))
+	x.property("dc:format", file.MimeType)
+	if !submission.CreateDateSystem.IsZero() {
+		x.property("xmp:CreateDate", submission.CreateDateSystem.UTC().Format(time.RFC3339))
+	}
+	if !submission.UpdateDateSystem.IsZero() {
+		x.property("xmp:ModifyDate", submission.UpdateDateSystem.UTC().Format(time.RFC3339))
+	}
+	x.property("inkbunny:SubmissionID", submission.SubmissionID.String())
+	x.property("inkbunny:FileID", file.FileID.String())
+	x.property("inkbunny:Page", fmt.Sprint(int(file.SubmissionFileOrder)+1))
+	x.property("inkbunny:Type", submission.TypeName)
+	x.property("inkbunny:Rating", submission.RatingName)
+	tags := make([]string, len(submission.Ratings))
+	for i, rating := range submission.Ratings {
+		tags[i] = rating.Name
+	}
+	x.list("inkbunny:ContentTags", "rdf:Bag", tags...)
+
+	x.raw("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	if x.err != nil {
+		return x.err
+	}
+	_, err := w.Write([]byte(x.String()))
+	return err
+}
+
+// pageURL returns the address of the page of a file, e.g. https://inkbunny.net/s/123456-p2.
+func pageURL(submission SubmissionDetails, file File) string {
+	u := submission.URL()
+	if file.SubmissionFileOrder > 0 {
+		u.Path += fmt.Sprintf("-p%d", int(file.SubmissionFileOrder)+1)
+	}
+	return u.String()
+}
+
+// xmpWriter writes the properties of an XMP sidecar, skipping empty values.
+type xmpWriter struct {
+	strings.Builder
+	err error
+}
+
+func (x *xmpWriter) raw(s string) {
+	x.WriteString(s)
+}
+
+func (x *xmpWriter) text(s string) {
+	if x.err == nil {
+		x.err = xml.EscapeText(x, []byte(s))
+	}
+}
+
+func (x *xmpWriter) property(name, value string) {
+	if value == "" {
+		return
+	}
+	x.raw("   <" + name + ">")
+	x.text(value)
+	x.raw("</" + name + ">\n")
+}
+
+func (x *xmpWriter) alt(name, value string) {
+	if value == "" {
+		return
+	}
+	x.raw("   <" + name + "><rdf:Alt><rdf:li xml:lang=\"x-default\">")
+	x.text(value)
+	x.raw("</rdf:li></rdf:Alt></" + name + ">\n")
+}
+
+func (x *xmpWriter) list(name, kind string, values ...string) {
+	if len(values) == 0 || len(values) == 1 && values[0] == "" {
+		return
+	}
+	x.raw("   <" + name + "><" + kind + ">\n")
+	for _, value := range values {
+		x.raw("    <rdf:li>")
+		x.text(value)
+		x.raw("</rdf:li>\n")
+	}
+	x.raw("   </" + kind + "></" + name + ">\n")
+}
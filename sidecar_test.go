@@ -0,0 +1,42 @@
+package inkbunny_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestGalleryDLSidecarDate(t *testing.T) {
+	created := types.Time{Time: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+	uploaded := types.Time{Time: time.Date(2024, 2, 3, 10, 0, 0, 0, time.UTC)}
+	for _, test := range []struct {
+		name       string
+		submission inkbunny.SubmissionDetails
+		file       inkbunny.File
+		want       any
+	}{
+		{"file", inkbunny.SubmissionDetails{SubmissionBasic: inkbunny.SubmissionBasic{CreateDateSystem: created}}, inkbunny.File{CreateDateTime: uploaded}, "2024-02-03 10:00:00"},
+		{"submission", inkbunny.SubmissionDetails{SubmissionBasic: inkbunny.SubmissionBasic{CreateDateSystem: created}}, inkbunny.File{}, "2024-01-02 15:04:05"},
+		{"none", inkbunny.SubmissionDetails{}, inkbunny.File{}, nil},
+	} {
+		var b bytes.Buffer
+		test.file.FileName = "page.png"
+		if err := (inkbunny.GalleryDLSidecar{}).Write(&b, test.submission, test.file); err != nil {
+			t.Fatal(err)
+		}
+		var metadata map[string]any
+		if err := json.Unmarshal(b.Bytes(), &metadata); err != nil {
+			t.Fatal(err)
+		}
+		if got := metadata["date"]; got != test.want {
+			t.Errorf("%s: got date %v, want %v", test.name, got, test.want)
+		}
+		if metadata["filename"] != "page" || metadata["extension"] != "png" {
+			t.Errorf("%s: got filename %v and extension %v", test.name, metadata["filename"], metadata["extension"])
+		}
+	}
+}
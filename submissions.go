@@ -1,10 +1,19 @@
 package inkbunny
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ellypaws/inkbunny/types"
+	"github.com/ellypaws/inkbunny/utils"
 )
 
 // SubmissionDetailsRequest is modified to use BooleanYN for fields requiring "yes" or "no" representation.
@@ -19,6 +28,29 @@ type SubmissionDetailsRequest struct {
 	ShowWriting                 types.BooleanYN  `json:"show_writing,omitempty" query:"show_writing"`
 	ShowWritingBbcodeParsed     types.BooleanYN  `json:"show_writing_bbcode_parsed,omitempty" query:"show_writing_bbcode_parsed"`
 	ShowPools                   types.BooleanYN  `json:"show_pools,omitempty" query:"show_pools"`
+
+	// ChunkSize is the maximum number of IDs sent per request. Defaults to MaxSubmissionIDs.
+	ChunkSize int `json:"-"`
+	// Concurrency is the number of chunks fetched at the same time. Defaults to 1.
+	Concurrency int `json:"-"`
+}
+
+// MaxSubmissionIDs is the maximum number of submission IDs the API accepts per request.
+// Larger requests are split into chunks, see SubmissionDetailsRequest.ChunkSize.
+const MaxSubmissionIDs = 100
+
+// ChunkError reports the failure of a chunk of a SubmissionDetailsRequest.
+type ChunkError struct {
+	SubmissionIDs []string
+	Err           error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("error getting details of %d submissions (%s...%s): %v", len(e.SubmissionIDs), e.SubmissionIDs[0], e.SubmissionIDs[len(e.SubmissionIDs)-1], e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
 }
 
 // SubmissionBasic combines elements common in SubmissionSearch and SubmissionDetails
@@ -27,9 +59,9 @@ type SubmissionBasic struct {
 	Hidden           types.BooleanYN `json:"hidden,omitempty"`
 	Username         string          `json:"username,omitempty"`
 	UserID           types.IntString `json:"user_id,omitempty"`
-	CreateDateSystem string          `json:"create_datetime,omitempty"`
+	CreateDateSystem types.Time      `json:"create_datetime,omitzero"`
 	CreateDateUser   string          `json:"create_datetime_usertime,omitempty"`
-	UpdateDateSystem string          `json:"last_file_update_datetime,omitempty"`
+	UpdateDateSystem types.Time      `json:"last_file_update_datetime,omitzero"`
 	UpdateDateUser   string          `json:"last_file_update_datetime_usertime,omitempty"`
 	FileName         string          `json:"file_name,omitempty"`
 	LatestFileName   string          `json:"latest_file_name,omitempty"`
@@ -102,7 +134,7 @@ type File struct {
 	FileDimensions
 	FileMD5
 	Deleted            types.BooleanYN `json:"deleted"`
-	CreateDateTime     string          `json:"create_datetime"`
+	CreateDateTime     types.Time      `json:"create_datetime"`
 	CreateDateTimeUser string          `json:"create_datetime_usertime"`
 }
 
@@ -166,6 +198,35 @@ type SubmissionRating struct {
 	RatingID     types.IntString `json:"rating_id"`
 }
 
+// Tag returns the ContentTagID as a types.ContentTag.
+func (r SubmissionRating) Tag() types.ContentTag {
+	return types.ContentTag(r.ContentTagID)
+}
+
+// Rating returns the RatingID as a types.Rating.
+func (s SubmissionBasic) Rating() types.Rating {
+	return types.Rating(s.RatingID)
+}
+
+// URL returns the address of the submission page on DefaultBaseURL, e.g. https://inkbunny.net/s/123456.
+func (s SubmissionBasic) URL() *url.URL {
+	return InkbunnyUrl("s/" + s.SubmissionID.String())
+}
+
+// ContentRatings returns the rating tags of the submission as a types.Ratings, with the other fields set to No.
+// Use it with types.Ratings.Allows to check whether the submission is allowed by a set of ratings.
+//
+//	if user.Ratings.Allows(details) {
+//		fmt.Println(details.Title)
+//	}
+func (s SubmissionDetails) ContentRatings() types.Ratings {
+	tags := make([]types.ContentTag, len(s.Ratings))
+	for i, r := range s.Ratings {
+		tags[i] = r.Tag()
+	}
+	return types.RatingsOf(tags...)
+}
+
 // LatestFileURL Full URL of the (SIZE) asset for the LATEST added file of this submission. SIZE can be one of "full, screen, preview".
 type LatestFileURL struct {
 	LatestFileURLFull    string `json:"latest_file_url_full"`
@@ -228,17 +289,35 @@ type SubmissionFavoritesResponse struct {
 	Users []types.UsernameID `json:"favingusers"`
 }
 
+// SubmissionDetails returns the details of the submissions of the request, in the order of the requested IDs.
+// Submissions that were not found are left out. See Client.SubmissionDetails.
 func (u *User) SubmissionDetails(req SubmissionDetailsRequest) (SubmissionDetailsResponse, error) {
+	return u.SubmissionDetailsContext(u.Client().Context(), req)
+}
+
+// SubmissionDetailsContext is like User.SubmissionDetails but uses ctx for the request.
+func (u *User) SubmissionDetailsContext(ctx context.Context, req SubmissionDetailsRequest) (SubmissionDetailsResponse, error) {
 	if req.SID == "" {
-		if u.SID == "" {
+		if u.sid() == "" {
 			return SubmissionDetailsResponse{}, ErrNotLoggedIn
 		}
-		req.SID = u.SID
+		req.SID = u.sid()
 	}
-	return u.Client().SubmissionDetails(req)
+	return withSession(ctx, u, req.SID, func(sid string) (SubmissionDetailsResponse, error) {
+		req.SID = sid
+		return u.Client().SubmissionDetailsContext(ctx, req)
+	})
 }
 
+// SubmissionDetails returns the details of the submissions of the request, in the order of the requested IDs,
+// whatever the order of the API. Submissions that were not found are left out.
+// Requests with more than ChunkSize IDs are split into chunks, see SubmissionDetailsRequest.ChunkSize.
 func (c *Client) SubmissionDetails(req SubmissionDetailsRequest) (SubmissionDetailsResponse, error) {
+	return c.SubmissionDetailsContext(c.Context(), req)
+}
+
+// SubmissionDetailsContext is like Client.SubmissionDetails but uses ctx for the request.
+func (c *Client) SubmissionDetailsContext(ctx context.Context, req SubmissionDetailsRequest) (SubmissionDetailsResponse, error) {
 	if req.SID == "" {
 		return SubmissionDetailsResponse{}, ErrEmptySID
 	}
@@ -249,18 +328,196 @@ func (c *Client) SubmissionDetails(req SubmissionDetailsRequest) (SubmissionDeta
 		req.SubmissionIDs += strings.Join(req.SubmissionIDSlice, ",")
 		req.SubmissionIDSlice = nil
 	}
-	return PostDecode[SubmissionDetailsResponse](c, ApiUrl("submissions"), req)
+	size := cmp.Or(req.ChunkSize, MaxSubmissionIDs)
+	ids := splitIDs(req.SubmissionIDs)
+	if len(ids) <= size {
+		response, err := PostDecodeContext[SubmissionDetailsResponse](ctx, c, c.ApiUrl("submissions"), req)
+		if err != nil {
+			return response, err
+		}
+		response.Submissions = inOrder(response.Submissions, ids)
+		response.ResultsCount = types.IntString(len(response.Submissions))
+		return response, nil
+	}
+	return c.submissionDetailsChunks(ctx, req, ids, size)
+}
+
+// submissionDetailsChunks requests the details of ids in chunks of size, and merges the responses in the order of ids.
+// Chunks that fail are reported as a ChunkError, joined with errors.Join, along with the submissions that were found.
+func (c *Client) submissionDetailsChunks(ctx context.Context, req SubmissionDetailsRequest, ids []string, size int) (SubmissionDetailsResponse, error) {
+	chunks := slices.Collect(slices.Chunk(ids, size))
+	responses := make([]SubmissionDetailsResponse, len(chunks))
+	errs := make([]error, len(chunks))
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, max(req.Concurrency, 1))
+	for i, chunk := range chunks {
+		wg.Add(1)
+		select {
+		case limit <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = &ChunkError{SubmissionIDs: chunk, Err: ctx.Err()}
+			wg.Done()
+			continue
+		}
+		go func() {
+			defer func() { <-limit; wg.Done() }()
+			request := req
+			request.SubmissionIDs = strings.Join(chunk, ",")
+			response, err := PostDecodeContext[SubmissionDetailsResponse](ctx, c, c.ApiUrl("submissions"), request)
+			if err != nil {
+				errs[i] = &ChunkError{SubmissionIDs: chunk, Err: err}
+				return
+			}
+			responses[i] = response
+		}()
+	}
+	wg.Wait()
+
+	merged := SubmissionDetailsResponse{SID: req.SID}
+	for _, response := range responses {
+		if response.SID != "" {
+			merged.SID = response.SID
+			merged.UserLocation = response.UserLocation
+		}
+		merged.Submissions = append(merged.Submissions, response.Submissions...)
+	}
+	merged.Submissions = inOrder(merged.Submissions, ids)
+	merged.ResultsCount = types.IntString(len(merged.Submissions))
+	return merged, errors.Join(errs...)
+}
+
+// inOrder returns the submissions in the order of ids, leaving out duplicates and submissions that were not requested.
+func inOrder(submissions []SubmissionDetails, ids []string) []SubmissionDetails {
+	found := make(map[types.IntString]SubmissionDetails, len(submissions))
+	for _, submission := range submissions {
+		found[submission.SubmissionID] = submission
+	}
+	ordered := make([]SubmissionDetails, 0, len(submissions))
+	for _, id := range ids {
+		i, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		if submission, ok := found[types.IntString(i)]; ok {
+			ordered = append(ordered, submission)
+			delete(found, types.IntString(i))
+		}
+	}
+	return ordered
+}
+
+// splitIDs splits a comma-separated list of IDs, ignoring empty entries.
+func splitIDs(ids string) []string {
+	var split []string
+	for _, id := range strings.Split(ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			split = append(split, id)
+		}
+	}
+	return split
 }
 
 func GetSubmissionDetails(req SubmissionDetailsRequest) (SubmissionDetailsResponse, error) {
 	return DefaultClient.SubmissionDetails(req)
 }
 
+// GetSubmissionDetailsContext is like GetSubmissionDetails but uses ctx for the request.
+func GetSubmissionDetailsContext(ctx context.Context, req SubmissionDetailsRequest) (SubmissionDetailsResponse, error) {
+	return DefaultClient.SubmissionDetailsContext(ctx, req)
+}
+
+// StreamSubmissionDetails returns a sequence of the submissions of the request, decoded from the response one at a time
+// instead of reading the whole response into memory. This is useful for large requests with descriptions and writings.
+// Requests with more than ChunkSize IDs are sent one chunk at a time, and Concurrency is ignored.
+// Submissions are yielded in the order of the responses, and the sequence stops after the first error.
+func (u *User) StreamSubmissionDetails(req SubmissionDetailsRequest) iter.Seq2[SubmissionDetails, error] {
+	return u.StreamSubmissionDetailsContext(u.Client().Context(), req)
+}
+
+// StreamSubmissionDetailsContext is like User.StreamSubmissionDetails but uses ctx for the requests.
+// If the session expired before any submission was yielded, the User logs in again like User.SubmissionDetails.
+func (u *User) StreamSubmissionDetailsContext(ctx context.Context, req SubmissionDetailsRequest) iter.Seq2[SubmissionDetails, error] {
+	return func(yield func(SubmissionDetails, error) bool) {
+		if req.SID == "" {
+			if u.sid() == "" {
+				yield(SubmissionDetails{}, ErrNotLoggedIn)
+				return
+			}
+			req.SID = u.sid()
+		}
+		own := req.SID == u.sid()
+		yielded := false
+		for submission, err := range u.Client().StreamSubmissionDetailsContext(ctx, req) {
+			if err != nil && !yielded && own && u.credentials() != nil && errors.Is(err, types.ErrInvalidSessionID) {
+				if err := u.relogin(ctx, req.SID); err != nil {
+					yield(SubmissionDetails{}, fmt.Errorf("session expired and could not log in again: %w", err))
+					return
+				}
+				req.SID = u.sid()
+				for submission, err := range u.Client().StreamSubmissionDetailsContext(ctx, req) {
+					if !yield(submission, err) {
+						return
+					}
+				}
+				return
+			}
+			yielded = true
+			if !yield(submission, err) {
+				return
+			}
+		}
+	}
+}
+
+// StreamSubmissionDetails returns a sequence of the submissions of the request, decoded from the response one at a time
+// instead of reading the whole response into memory. This is useful for large requests with descriptions and writings.
+// Requests with more than ChunkSize IDs are sent one chunk at a time, and Concurrency is ignored.
+// Submissions are yielded in the order of the responses, and the sequence stops after the first error.
+func (c *Client) StreamSubmissionDetails(req SubmissionDetailsRequest) iter.Seq2[SubmissionDetails, error] {
+	return c.StreamSubmissionDetailsContext(c.Context(), req)
+}
+
+// StreamSubmissionDetailsContext is like Client.StreamSubmissionDetails but uses ctx for the requests.
+func (c *Client) StreamSubmissionDetailsContext(ctx context.Context, req SubmissionDetailsRequest) iter.Seq2[SubmissionDetails, error] {
+	return func(yield func(SubmissionDetails, error) bool) {
+		if req.SID == "" {
+			yield(SubmissionDetails{}, ErrEmptySID)
+			return
+		}
+		ids := splitIDs(req.SubmissionIDs)
+		for _, id := range req.SubmissionIDSlice {
+			ids = append(ids, splitIDs(id)...)
+		}
+		req.SubmissionIDSlice = nil
+		for chunk := range slices.Chunk(ids, cmp.Or(req.ChunkSize, MaxSubmissionIDs)) {
+			req.SubmissionIDs = strings.Join(chunk, ",")
+			response, err := c.PostFormContext(ctx, c.ApiUrl("submissions"), req)
+			if err != nil {
+				yield(SubmissionDetails{}, err)
+				return
+			}
+			for submission, err := range utils.ParseResponseArray[SubmissionDetails](response, "submissions") {
+				if !yield(submission, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
 // SubmissionFavorites retrieves the list of users who have favorited a specific submission.
 func (u *User) SubmissionFavorites(id types.IntString) (SubmissionFavoritesResponse, error) {
-	if u.SID == "" {
+	return u.SubmissionFavoritesContext(u.Client().Context(), id)
+}
+
+// SubmissionFavoritesContext is like User.SubmissionFavorites but uses ctx for the request.
+func (u *User) SubmissionFavoritesContext(ctx context.Context, id types.IntString) (SubmissionFavoritesResponse, error) {
+	if u.sid() == "" {
 		return SubmissionFavoritesResponse{}, ErrNotLoggedIn
 	}
-	val := url.Values{"sid": {u.SID}, "submission_id": {id.String()}}
-	return PostDecode[SubmissionFavoritesResponse](u.Client(), ApiUrl("submissionfavingusers"), val)
+	return withSession(ctx, u, u.sid(), func(sid string) (SubmissionFavoritesResponse, error) {
+		val := url.Values{"sid": {sid}, "submission_id": {id.String()}}
+		return PostDecodeContext[SubmissionFavoritesResponse](ctx, u.Client(), u.Client().ApiUrl("submissionfavingusers"), val)
+	})
 }
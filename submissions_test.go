@@ -0,0 +1,93 @@
+package inkbunny_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// newestFirst returns a Client for a server that returns submission details by descending ID, whatever the
+// order of the request.
+func newestFirst(t *testing.T) *inkbunny.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ids []int
+		for _, id := range strings.Split(r.FormValue("submission_ids"), ",") {
+			if n, err := strconv.Atoi(id); err == nil {
+				ids = append(ids, n)
+			}
+		}
+		slices.Sort(ids)
+		slices.Reverse(ids)
+		response := inkbunny.SubmissionDetailsResponse{SID: r.FormValue("sid")}
+		for _, id := range ids {
+			response.Submissions = append(response.Submissions, inkbunny.SubmissionDetails{
+				SubmissionBasic: inkbunny.SubmissionBasic{SubmissionID: types.IntString(id)},
+			})
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	base, _ := url.Parse(server.URL)
+	return inkbunny.NewClient(inkbunny.WithBaseURL(base))
+}
+
+func TestSubmissionDetailsOrder(t *testing.T) {
+	client := newestFirst(t)
+	ids := []string{"3", "1", "5", "2", "4", "1"}
+	want := []types.IntString{3, 1, 5, 2, 4}
+	for _, size := range []int{0, 2} {
+		response, err := client.SubmissionDetails(inkbunny.SubmissionDetailsRequest{
+			SID:               "sid",
+			SubmissionIDSlice: ids,
+			ChunkSize:         size,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []types.IntString
+		for _, submission := range response.Submissions {
+			got = append(got, submission.SubmissionID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("chunk size %d: got %v, want %v", size, got, want)
+		}
+		if response.ResultsCount != types.IntString(len(want)) {
+			t.Errorf("chunk size %d: got results count %d, want %d", size, response.ResultsCount, len(want))
+		}
+	}
+}
+
+func TestSubmissionDetailsCancelled(t *testing.T) {
+	client := newestFirst(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.SubmissionDetailsContext(ctx, inkbunny.SubmissionDetailsRequest{
+		SID:               "sid",
+		SubmissionIDSlice: []string{"1", "2", "3", "4", "5"},
+		ChunkSize:         1,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	var chunks int
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var chunkErr *inkbunny.ChunkError
+		if errors.As(err, &chunkErr) && errors.Is(chunkErr, context.Canceled) {
+			chunks++
+		}
+	}
+	if chunks != 5 {
+		t.Errorf("got %d cancelled chunks, want 5", chunks)
+	}
+}
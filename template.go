@@ -0,0 +1,247 @@
+package inkbunny
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// PathTemplate builds relative file paths from a SubmissionDetails and one of its Files.
+// Use ParsePathTemplate to create one.
+//
+// Placeholders are written as {name} or {name:argument}, and "{{" and "}}" are literal braces.
+// Paths are separated with "/", and segments that are empty once filled in are removed.
+//
+//   - {username}, {user_id}, {submission_id}, {title}, {type_name}, {rating}
+//   - {file_id}, {file_name} (without extension), {ext} (without the dot), {md5}
+//   - {file_order} starting at 0, and {page} starting at 1. A width pads them with zeros, e.g. {page:03}.
+//   - {create_date} and {update_date}, formatted with a time layout, e.g. {create_date:2006-01}. Defaults to 2006-01-02.
+//   - {pool} and {pool_id}, of the first pool of the submission, or empty if it is in none.
+//
+// Every segment is sanitized to be valid on Windows, macOS and Linux.
+type PathTemplate struct {
+	template string
+	parts    []templatePart
+}
+
+type templatePart struct {
+	literal  string
+	name     string
+	argument string
+}
+
+// templateFields are the placeholders of a PathTemplate.
+var templateFields = map[string]func(s SubmissionDetails, f File, argument string) string{
+	"username":      func(s SubmissionDetails, _ File, _ string) string { return s.Username },
+	"user_id":       func(s SubmissionDetails, _ File, _ string) string { return strconv.Itoa(int(s.UserID)) },
+	"submission_id": func(s SubmissionDetails, _ File, _ string) string { return strconv.Itoa(int(s.SubmissionID)) },
+	"title":         func(s SubmissionDetails, _ File, _ string) string { return s.Title },
+	"type_name":     func(s SubmissionDetails, _ File, _ string) string { return s.TypeName },
+	"rating":        func(s SubmissionDetails, _ File, _ string) string { return cmp.Or(s.RatingName, s.Rating().String()) },
+	"file_id":       func(_ SubmissionDetails, f File, _ string) string { return strconv.Itoa(int(f.FileID)) },
+	"file_name": func(_ SubmissionDetails, f File, _ string) string {
+		return strings.TrimSuffix(f.FileName, path.Ext(f.FileName))
+	},
+	"ext": func(_ SubmissionDetails, f File, _ string) string {
+		return strings.TrimPrefix(path.Ext(f.FileName), ".")
+	},
+	"md5": func(_ SubmissionDetails, f File, _ string) string { return f.FullFileMD5 },
+	"file_order": func(_ SubmissionDetails, f File, width string) string {
+		return pad(int(f.SubmissionFileOrder), width)
+	},
+	"page": func(_ SubmissionDetails, f File, width string) string {
+		return pad(int(f.SubmissionFileOrder)+1, width)
+	},
+	"create_date": func(s SubmissionDetails, _ File, layout string) string { return formatDate(s.CreateDateSystem, layout) },
+	"update_date": func(s SubmissionDetails, _ File, layout string) string { return formatDate(s.UpdateDateSystem, layout) },
+	"pool": func(s SubmissionDetails, _ File, _ string) string {
+		if len(s.Pools) == 0 {
+			return ""
+		}
+		return s.Pools[0].Name
+	},
+	"pool_id": func(s SubmissionDetails, _ File, _ string) string {
+		if len(s.Pools) == 0 {
+			return ""
+		}
+		return strconv.Itoa(int(s.Pools[0].PoolID))
+	},
+}
+
+// ParsePathTemplate parses a PathTemplate, e.g. "{username}/{create_date:2006-01}/{submission_id}_{page:02}.{ext}".
+func ParsePathTemplate(template string) (*PathTemplate, error) {
+	t := &PathTemplate{template: template}
+	var literal strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '{' && strings.HasPrefix(template[i:], "{{"), c == '}' && strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("path template %q: unclosed placeholder at %d", template, i)
+			}
+			name, argument, _ := strings.Cut(template[i+1:i+end], ":")
+			if _, ok := templateFields[name]; !ok {
+				return nil, fmt.Errorf("path template %q: unknown placeholder {%s}", template, name)
+			}
+			if name == "file_order" || name == "page" {
+				if _, err := strconv.Atoi(cmp.Or(argument, "0")); err != nil {
+					return nil, fmt.Errorf("path template %q: invalid width %q for {%s}", template, argument, name)
+				}
+			}
+			if literal.Len() > 0 {
+				t.parts = append(t.parts, templatePart{literal: literal.String()})
+				literal.Reset()
+			}
+			t.parts = append(t.parts, templatePart{name: name, argument: argument})
+			i += end
+		case c == '}':
+			return nil, fmt.Errorf("path template %q: unexpected } at %d", template, i)
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	if literal.Len() > 0 {
+		t.parts = append(t.parts, templatePart{literal: literal.String()})
+	}
+	return t, nil
+}
+
+// MustParsePathTemplate is like ParsePathTemplate but panics if the template is invalid.
+func MustParsePathTemplate(template string) *PathTemplate {
+	t, err := ParsePathTemplate(template)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (t *PathTemplate) String() string {
+	return t.template
+}
+
+// Execute returns the path of a file of a submission, using "/" as the separator.
+// Values are sanitized so that they cannot add path segments, and every segment is a valid file name.
+func (t *PathTemplate) Execute(submission SubmissionDetails, file File) string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.name == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+		value := templateFields[part.name](submission, file, part.argument)
+		b.WriteString(strings.NewReplacer("/", "_", `\`, "_").Replace(value))
+	}
+	var segments []string
+	for _, segment := range strings.Split(b.String(), "/") {
+		if segment = sanitize(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return "_"
+	}
+	return strings.Join(segments, "/")
+}
+
+// Namer returns a function like Execute that avoids collisions between files.
+// When a path was already returned for another file, the file ID is added before the extension, such as
+// "page_123456.png", so that a file gets the same suffix in every run. Files without an ID get a counter such as "_2".
+// The first file to get a path keeps it, so which file keeps the path without a suffix depends on the order of the
+// calls; Downloader names the files of each download by ascending file ID.
+// The same file always gets the same path, and paths that differ only by case are treated as collisions.
+// The function is safe for concurrent use.
+func (t *PathTemplate) Namer() func(submission SubmissionDetails, file File) string {
+	return uniqueNames(t.Execute)
+}
+
+// uniqueNames wraps name to avoid collisions between files, see PathTemplate.Namer.
+func uniqueNames(execute func(submission SubmissionDetails, file File) string) func(submission SubmissionDetails, file File) string {
+	var mu sync.Mutex
+	byFile := make(map[types.IntString]string)
+	used := make(map[string]bool)
+	return func(submission SubmissionDetails, file File) string {
+		mu.Lock()
+		defer mu.Unlock()
+		if name, ok := byFile[file.FileID]; ok && file.FileID != 0 {
+			return name
+		}
+		name := execute(submission, file)
+		ext := path.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		if used[strings.ToLower(name)] && file.FileID != 0 {
+			name = base + "_" + file.FileID.String() + ext
+		}
+		for i := 2; used[strings.ToLower(name)]; i++ {
+			name = base + "_" + strconv.Itoa(i) + ext
+		}
+		used[strings.ToLower(name)] = true
+		byFile[file.FileID] = name
+		return name
+	}
+}
+
+// maxSegment is the maximum length in bytes of a path segment, which most filesystems limit to 255.
+const maxSegment = 240
+
+// reserved are the file names reserved on Windows, regardless of their extension.
+var reserved = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// sanitize makes a path segment a valid file name on every common filesystem.
+// Invalid characters are replaced with "_", trailing dots and spaces are removed, and long names are shortened
+// while keeping their extension.
+func sanitize(segment string) string {
+	segment = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(segment))
+	segment = strings.TrimRight(segment, ". ")
+	if segment == "" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(segment, "."); reserved[strings.ToLower(name)] {
+		segment = "_" + segment
+	}
+	if len(segment) > maxSegment {
+		ext := path.Ext(segment)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := segment[:maxSegment-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		segment = strings.TrimRight(base, ". ") + ext
+	}
+	return segment
+}
+
+func pad(n int, width string) string {
+	w, _ := strconv.Atoi(width)
+	return fmt.Sprintf("%0*d", w, n)
+}
+
+func formatDate(t types.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(cmp.Or(layout, time.DateOnly))
+}
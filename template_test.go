@@ -0,0 +1,121 @@
+package inkbunny_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestNamerSuffix(t *testing.T) {
+	template := inkbunny.MustParsePathTemplate("{username}/{title}.{ext}")
+	var submissions []inkbunny.SubmissionDetails
+	for _, id := range []types.IntString{10, 20, 30} {
+		submissions = append(submissions, inkbunny.SubmissionDetails{
+			SubmissionBasic: inkbunny.SubmissionBasic{SubmissionID: id, Username: "artist", Title: "Page"},
+			Files:           []inkbunny.File{{FileID: id + 1, FileName: "page.png"}},
+		})
+	}
+	for _, test := range []struct {
+		order []int
+		want  map[types.IntString]string
+	}{
+		{[]int{0, 1, 2}, map[types.IntString]string{11: "artist/Page.png", 21: "artist/Page_21.png", 31: "artist/Page_31.png"}},
+		{[]int{0, 2, 1}, map[types.IntString]string{11: "artist/Page.png", 21: "artist/Page_21.png", 31: "artist/Page_31.png"}},
+		{[]int{2, 1, 0}, map[types.IntString]string{11: "artist/Page_11.png", 21: "artist/Page_21.png", 31: "artist/Page.png"}},
+	} {
+		namer := template.Namer()
+		for range 2 {
+			for _, i := range test.order {
+				file := submissions[i].Files[0]
+				if got := namer(submissions[i], file); got != test.want[file.FileID] {
+					t.Errorf("order %v: file %d: got %q, want %q", test.order, file.FileID, got, test.want[file.FileID])
+				}
+			}
+		}
+	}
+}
+
+func TestParsePathTemplate(t *testing.T) {
+	for _, template := range []string{
+		"{username}/{unknown}",
+		"{username",
+		"username}",
+		"{page:two}",
+		"{file_order:x}",
+	} {
+		if _, err := inkbunny.ParsePathTemplate(template); err == nil {
+			t.Errorf("%q: got no error", template)
+		}
+	}
+	for _, template := range []string{
+		"{username}/{create_date:2006-01}/{submission_id}_{page:02}.{ext}",
+		"{{literal}}/{page}",
+		"no placeholders",
+	} {
+		parsed, err := inkbunny.ParsePathTemplate(template)
+		if err != nil {
+			t.Errorf("%q: %v", template, err)
+			continue
+		}
+		if parsed.String() != template {
+			t.Errorf("%q: got String %q", template, parsed)
+		}
+	}
+}
+
+func TestPathTemplateExecute(t *testing.T) {
+	submission := inkbunny.SubmissionDetails{
+		SubmissionBasic: inkbunny.SubmissionBasic{
+			SubmissionID:     123,
+			UserID:           45,
+			Username:         "artist",
+			Title:            "A/B: c?",
+			TypeName:         "Comic",
+			CreateDateSystem: types.Time{Time: time.Date(2024, 3, 5, 23, 0, 0, 0, time.UTC)},
+		},
+		Pools: []inkbunny.Pool{{PoolID: 7, Name: "Series"}},
+	}
+	file := inkbunny.File{FileID: 678, FileName: "drawing.final.png", SubmissionFileOrder: 2}
+	file.FullFileMD5 = "abc"
+	long := strings.Repeat("é", 200)
+	for _, test := range []struct {
+		template   string
+		submission func(*inkbunny.SubmissionDetails)
+		want       string
+	}{
+		{"{username}/{submission_id}_{page:03}.{ext}", nil, "artist/123_003.png"},
+		{"{file_order}-{page}-{page:2}", nil, "2-3-03"},
+		{"{file_name}_{file_id}_{md5}", nil, "drawing.final_678_abc"},
+		{"{create_date}/{create_date:2006-01}/{update_date}", nil, "2024-03-05/2024-03"},
+		{"{pool}/{pool_id}/{title}", nil, "Series/7/A_B_ c_"},
+		{"{pool}/{title}", func(s *inkbunny.SubmissionDetails) { s.Pools = nil }, "A_B_ c_"},
+		{"{{{username}}}/{{x}}", nil, "{artist}/{x}"},
+		{"{type_name}/{user_id}/{rating}", nil, "Comic/45/General"},
+		{"{title}.{ext}", func(s *inkbunny.SubmissionDetails) { s.Title = "con" }, "_con.png"},
+		{"{title}", func(s *inkbunny.SubmissionDetails) { s.Title = "LPT1.txt" }, "_LPT1.txt"},
+		{"{title}/{page}", func(s *inkbunny.SubmissionDetails) { s.Title = "the end... " }, "the end/3"},
+		{"{title}/{page}", func(s *inkbunny.SubmissionDetails) { s.Title = ".." }, "3"},
+		{"{title}", func(s *inkbunny.SubmissionDetails) { s.Title = "tab\there\x00" }, "tab_here_"},
+		{"{pool}", func(s *inkbunny.SubmissionDetails) { s.Pools = nil }, "_"},
+		{"{title}.{ext}", func(s *inkbunny.SubmissionDetails) { s.Title = long }, strings.Repeat("é", 118) + ".png"},
+		{"{title}", func(s *inkbunny.SubmissionDetails) { s.Title = "a" + long }, "a" + strings.Repeat("é", 119)},
+	} {
+		s := submission
+		if test.submission != nil {
+			test.submission(&s)
+		}
+		got := inkbunny.MustParsePathTemplate(test.template).Execute(s, file)
+		if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.template, got, test.want)
+		}
+		for _, segment := range strings.Split(got, "/") {
+			if len(segment) > 240 || !utf8.ValidString(segment) {
+				t.Errorf("%q: got invalid segment %q", test.template, segment)
+			}
+		}
+	}
+}
@@ -170,18 +170,20 @@ const (
 type OrderBy = string
 
 const (
-	OrderByDefault        OrderBy = "create_datetime"
-	OrderByCreateDatetime OrderBy = "create_datetime"
-	OrderByUnreadDatetime OrderBy = "unread_datetime"
-	OrderByViews          OrderBy = "views"
-	OrderByTotalPrint     OrderBy = "total_print_sales"
-	OrderByTotalDigital   OrderBy = "total_digital_sales"
-	OrderByTotalSales     OrderBy = "total_sales"
-	OrderByUsername       OrderBy = "username"
-	OrderByFavDatetime    OrderBy = "fav_datetime"
-	OrderByFavStars       OrderBy = "fav_stars"
-	OrderByFavs           OrderBy = "favs" // undocumented but exists
-	OrderByPoolOrder      OrderBy = "pool_order"
+	OrderByDefault                OrderBy = "create_datetime"
+	OrderByCreateDatetime         OrderBy = "create_datetime"
+	OrderByLastFileUpdateDatetime OrderBy = "last_file_update_datetime"
+	OrderByUnreadDatetime         OrderBy = "unread_datetime"
+	OrderByUnreadDatetimeReverse  OrderBy = "unread_datetime_reverse"
+	OrderByViews                  OrderBy = "views"
+	OrderByTotalPrint             OrderBy = "total_print_sales"
+	OrderByTotalDigital           OrderBy = "total_digital_sales"
+	OrderByTotalSales             OrderBy = "total_sales"
+	OrderByUsername               OrderBy = "username"
+	OrderByFavDatetime            OrderBy = "fav_datetime"
+	OrderByFavStars               OrderBy = "fav_stars"
+	OrderByFavs                   OrderBy = "favs" // undocumented but exists
+	OrderByPoolOrder              OrderBy = "pool_order"
 )
 
 // SalesFilter for SubmissionSearchRequest.
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"fmt"
+	"net/http"
+)
+
 type ErrorResponse struct {
 	Code    *int   `json:"error_code,omitempty"`
 	Message string `json:"error_message"`
@@ -9,53 +14,168 @@ func (error ErrorResponse) Error() string {
 	return error.Message
 }
 
+// ErrorCode is an Inkbunny API error code. It implements error so that the constants below can be used as sentinels.
+//
+//	if errors.Is(err, types.ErrInvalidSessionID) {
+//		// log in again
+//	}
+type ErrorCode int
+
+func (e ErrorCode) Error() string {
+	if message, ok := errorMessages[e]; ok {
+		return fmt.Sprintf("[%d]: %s", e, message)
+	}
+	return fmt.Sprintf("[%d]: unknown error code", e)
+}
+
+// APIError is returned when the Inkbunny API responds with an error_code or an unexpected HTTP status.
+// Use errors.As to retrieve it, or errors.Is with an ErrorCode to match the code.
+//
+//	var apiErr *types.APIError
+//	if errors.As(err, &apiErr) {
+//		fmt.Println(apiErr.Endpoint, apiErr.Code)
+//	}
+type APIError struct {
+	Code       ErrorCode // Code is the error_code sent by the API, or ErrUnexpectedStatus if none was sent.
+	Message    string    // Message is the error_message sent by the API, or the HTTP status.
+	Endpoint   string    // Endpoint is the path of the request, e.g. "/api_search.php".
+	StatusCode int       // StatusCode is the HTTP status code of the response.
+}
+
+func (e *APIError) Error() string {
+	if e.Code == ErrUnexpectedStatus {
+		return fmt.Sprintf("%s: unexpected status code %s (%d)", e.Endpoint, e.Message, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: [%d]: %s", e.Endpoint, e.Code, e.Message)
+}
+
+// Is reports whether target is the same ErrorCode as the APIError.
+func (e *APIError) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	return ok && code == e.Code
+}
+
+// Unwrap returns the ErrorCode so that it can be matched with errors.Is and errors.As.
+func (e *APIError) Unwrap() error {
+	return e.Code
+}
+
+// Temporary reports whether sending the same request again might succeed.
+// This is true for database and file system errors on Inkbunny's side, 5xx and 429 HTTP statuses.
+func (e *APIError) Temporary() bool {
+	switch e.Code {
+	case ErrDatabaseError, ErrCouldNotCreateEntry, ErrCouldNotCreateCopyOfFile, ErrCouldNotCreateThumbnail,
+		ErrCouldNotCreateZIPtmpExtractionDir, ErrCouldNotRenameFileInUnzipProcess:
+		return true
+	case ErrUnexpectedStatus:
+		return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
+	default:
+		return false
+	}
+}
+
+// ErrUnexpectedStatus is not sent by Inkbunny. It is used by APIError when the response had a non-200 HTTP status and no error_code.
+const ErrUnexpectedStatus ErrorCode = -1
+
 const (
-	ErrInvalidLogin                      = iota // Invalid login. Username and password incorrect or account does not have API Access enabled in account Settings.
-	ErrEmptySessionID                           // No Session ID sent as variable 'sid'. If this error appears then a valid session ID is required as part of the query, but it was not received by the script. Session Ids are obtained by logging in using the api_login.php Login script.
-	ErrInvalidSessionID                         // Invalid Session ID sent as variable 'sid'. This error will appear if you send a Session ID (sid) that is not valid, has been logged out or has expired.
-	ErrInvalidResultsID                         // Invalid Results ID sent as variable 'rid'. It contains invalid characters. Results Ids can only contain Hexadecimal values.
-	ErrNoResultsFound                           // No results found for Results ID sent as variable 'rid'. The Results ID (rid) sent has either expired or is not valid. Results sets will automatically be removed after not being accessed for a period of time, or when a user has created too many results sets (in which case the oldest results sets will be removed first).
-	ErrNoPermissionToUpload                     // Current user does not have permission to upload files.
-	ErrHourlyLimitReached                       // Submission Hourly Limit Reached. This policy exists to prevent spamming and flooding. We apologise for the inconvenience. Please wait a while and try again to add more uploads.
-	ErrDatabaseError                            // Database error. Unable to create a new submission.
-	ErrNoValidSubmissionID                      // No valid submission id given.
-	ErrNoPermissionToEditSubmission             // Current user does not have permission to edit this submission.
-	ErrNoPermissionToEditFile                   // Current user does not have permission to edit this file.
-	ErrCouldNotCreateEntry                      // Could not create an entry for the file (filename) in our database. Please try again. If the problem persists, contact an administrator.
-	ErrNoPermissionToBulkUpload                 // User does not have permission to BULK upload multiple pages/files at once.
-	ErrZIPFileTooBig                            // ZIP file is too big.
-	ErrInvalidFileName                          // Incoming file names cannot contain a double-dot '..'. Please rename your file and try again.
-	ErrInvalidCharactersInFilenames             // Invalid characters detected in filenames inside your zip file. The server said (error message).
-	ErrCouldNotExtractFiles                     // Could not extract any files from that ZIP. ZIP files cannot have subdirectories in them for Bulk Upload. Please check the zip file is not damaged and that it has files in it. If you are sure your zip file is fine, please contact an administrator and tell them about this message.
-	ErrNotZIPFile                               // The file you uploaded was not a ZIP file. It was of a non-ZIP file type (type). Please try again with a valid ZIP file. If the problem persists, contact an administrator.
-	ErrZIPUploadFailed                          // ZIP upload failed. You might not have provided a file, or the file may have been too big for the size restrictions. If you are sure the file is fine, then the server may be out of space or the tmp uploads directory is not writable. Please contact a system administrator and tell them about this message if you are sure the problem is on our end.
-	ErrFileCouldNotBeRead                       // File could not be read. File name was (file name).
-	ErrFileTooLarge                             // The file you uploaded (file name) was too large in file size. Please try again with a smaller file size. If the problem persists, contact an administrator.
-	ErrUnsupportedFileType                      // The file you uploaded (file name) was of an unsupported file type (type). Please try again with a supported file type as listed. If the problem persists, contact an administrator.
-	ErrFileTooLargeInPixelSize                  // The file you uploaded (file name) was too large in pixel size (width and/or height). Please try again with dimensions not exceeding those listed. If the problem persists, contact an administrator.
-	ErrFileNotInRGBOrGreyscale                  // The file you uploaded (file name) was not in RGB or Greyscale color mode (most likely it was in CMYK mode). Please try again with an RGB or Greyscale image. If the problem persists, contact an administrator.
-	ErrUnknownErrorCheckingFile                 // There was an unknown error when trying to check your uploaded file (file name). Please check your file meets all the listed requirements and try again. If the problem persists, contact an administrator.
-	ErrUnsupportedThumbnailType                 // The thumbnail you uploaded (file name) was of an unsupported file type (type). Please try again with a supported thumbnail type as listed. If the problem persists, contact an administrator.
-	ErrThumbnailTooLargeInPixelSize             // The thumbnail you uploaded (file name) was too large in pixel size (width and/or height). Please try again with dimensions not exceeding those listed. If the problem persists, contact an administrator.
-	ErrThumbnailNotInRGBOrGreyscale             // The thumbnail you uploaded (file name) was not in RGB or Greyscale color mode (most likely it was in CMYK mode). Please try again with an RGB or Greyscale thumbnail. If the problem persists, contact an administrator.
-	ErrUnknownErrorCheckingThumbnail            // There was an unknown error when trying to check your uploaded thumbnail (file name). Please check your thumbnail meets all the listed requirements and try again. If the problem persists, contact an administrator.
-	ErrTooManySubmissionIDs                     // If you upload all the files in this ZIP file, you will exceed the maximum limit of files/pages per submission. None of the files from your zip were added. Please upload less pages in the one zip file or start a new submission for the remaining pages.
-	ErrCancellationRequest                      // Received cancellation request or didn't receive response from browser within timeout limit. Some files may have been uploaded successfully. Stopped uploading on file (file name). That file and any after it in the zip were not added.
-	ErrMaxAllowedNumberOfFiles                  // You have reached the maximum allowed number of files/pages for this submission. Stopped uploading on file (file name). That file and any after it in the zip were not added.
-	ErrCouldNotUploadThumbnail                  // Could not upload the thumbnail (file name). Please try again. If the problem persists, contact an administrator.
-	ErrCouldNotCreateCopyOfFile                 // Could not create copy of that file in our system. Please try again. If the file is a PNG, make sure it is in RGB color mode and not Indexed color. If the problem persists, contact an administrator.
-	ErrCouldNotCreateThumbnail                  // Could not create a thumbnail for the file (file name). Thumbnail file was called (thumbnail file name). If the file is a PNG, make sure it is in RGB color mode and not Indexed color. Please try again. If the problem persists, contact an administrator.
-	ErrUserCanceled                             // User canceled.
-	ErrInvalidProgressKey                       // Invalid Progress Key.
-	ErrNoPermissionToDeleteSubmission           // Current user does not have permission to delete this submission.
-	ErrNoPermissionToRemoveFile                 // Current user does not have permission to remove this file.
-	ErrNoPermissionToChangeOrderOfFile          // Current user does not have permission to change the order of this file.
-	ErrSubmissionDeleted                        // That submission has been deleted.
-	ErrTooManySubmissionIDsToQuery              // Too many submission ids to query. Limit exceeded.
-	ErrNoPermissionToGetFavlist                 // Current user does not have permission to get the favlist of this submission.
-	ErrCouldNotCreateZIPtmpExtractionDir        // Couldn't create zip tmp extraction dir.
-	ErrCouldNotRenameFileInUnzipProcess         // Could not rename file in unzip process.
-	ErrInvalidKeywordID                         // Invalid Keyword ID.
-	ErrCouldNotReplaceFileOrThumbnail           // Could not replace that file or thumbnail.
-	ErrRequestNotSentInHTTPSMode         = 999  // Request was not sent in HTTPS mode.
+	ErrInvalidLogin                      ErrorCode = iota // Invalid login. Username and password incorrect or account does not have API Access enabled in account Settings.
+	ErrEmptySessionID                                     // No Session ID sent as variable 'sid'. If this error appears then a valid session ID is required as part of the query, but it was not received by the script. Session Ids are obtained by logging in using the api_login.php Login script.
+	ErrInvalidSessionID                                   // Invalid Session ID sent as variable 'sid'. This error will appear if you send a Session ID (sid) that is not valid, has been logged out or has expired.
+	ErrInvalidResultsID                                   // Invalid Results ID sent as variable 'rid'. It contains invalid characters. Results Ids can only contain Hexadecimal values.
+	ErrNoResultsFound                                     // No results found for Results ID sent as variable 'rid'. The Results ID (rid) sent has either expired or is not valid. Results sets will automatically be removed after not being accessed for a period of time, or when a user has created too many results sets (in which case the oldest results sets will be removed first).
+	ErrNoPermissionToUpload                               // Current user does not have permission to upload files.
+	ErrHourlyLimitReached                                 // Submission Hourly Limit Reached. This policy exists to prevent spamming and flooding. We apologise for the inconvenience. Please wait a while and try again to add more uploads.
+	ErrDatabaseError                                      // Database error. Unable to create a new submission.
+	ErrNoValidSubmissionID                                // No valid submission id given.
+	ErrNoPermissionToEditSubmission                       // Current user does not have permission to edit this submission.
+	ErrNoPermissionToEditFile                             // Current user does not have permission to edit this file.
+	ErrCouldNotCreateEntry                                // Could not create an entry for the file (filename) in our database. Please try again. If the problem persists, contact an administrator.
+	ErrNoPermissionToBulkUpload                           // User does not have permission to BULK upload multiple pages/files at once.
+	ErrZIPFileTooBig                                      // ZIP file is too big.
+	ErrInvalidFileName                                    // Incoming file names cannot contain a double-dot '..'. Please rename your file and try again.
+	ErrInvalidCharactersInFilenames                       // Invalid characters detected in filenames inside your zip file. The server said (error message).
+	ErrCouldNotExtractFiles                               // Could not extract any files from that ZIP. ZIP files cannot have subdirectories in them for Bulk Upload. Please check the zip file is not damaged and that it has files in it. If you are sure your zip file is fine, please contact an administrator and tell them about this message.
+	ErrNotZIPFile                                         // The file you uploaded was not a ZIP file. It was of a non-ZIP file type (type). Please try again with a valid ZIP file. If the problem persists, contact an administrator.
+	ErrZIPUploadFailed                                    // ZIP upload failed. You might not have provided a file, or the file may have been too big for the size restrictions. If you are sure the file is fine, then the server may be out of space or the tmp uploads directory is not writable. Please contact a system administrator and tell them about this message if you are sure the problem is on our end.
+	ErrFileCouldNotBeRead                                 // File could not be read. File name was (file name).
+	ErrFileTooLarge                                       // The file you uploaded (file name) was too large in file size. Please try again with a smaller file size. If the problem persists, contact an administrator.
+	ErrUnsupportedFileType                                // The file you uploaded (file name) was of an unsupported file type (type). Please try again with a supported file type as listed. If the problem persists, contact an administrator.
+	ErrFileTooLargeInPixelSize                            // The file you uploaded (file name) was too large in pixel size (width and/or height). Please try again with dimensions not exceeding those listed. If the problem persists, contact an administrator.
+	ErrFileNotInRGBOrGreyscale                            // The file you uploaded (file name) was not in RGB or Greyscale color mode (most likely it was in CMYK mode). Please try again with an RGB or Greyscale image. If the problem persists, contact an administrator.
+	ErrUnknownErrorCheckingFile                           // There was an unknown error when trying to check your uploaded file (file name). Please check your file meets all the listed requirements and try again. If the problem persists, contact an administrator.
+	ErrUnsupportedThumbnailType                           // The thumbnail you uploaded (file name) was of an unsupported file type (type). Please try again with a supported thumbnail type as listed. If the problem persists, contact an administrator.
+	ErrThumbnailTooLargeInPixelSize                       // The thumbnail you uploaded (file name) was too large in pixel size (width and/or height). Please try again with dimensions not exceeding those listed. If the problem persists, contact an administrator.
+	ErrThumbnailNotInRGBOrGreyscale                       // The thumbnail you uploaded (file name) was not in RGB or Greyscale color mode (most likely it was in CMYK mode). Please try again with an RGB or Greyscale thumbnail. If the problem persists, contact an administrator.
+	ErrUnknownErrorCheckingThumbnail                      // There was an unknown error when trying to check your uploaded thumbnail (file name). Please check your thumbnail meets all the listed requirements and try again. If the problem persists, contact an administrator.
+	ErrTooManySubmissionIDs                               // If you upload all the files in this ZIP file, you will exceed the maximum limit of files/pages per submission. None of the files from your zip were added. Please upload less pages in the one zip file or start a new submission for the remaining pages.
+	ErrCancellationRequest                                // Received cancellation request or didn't receive response from browser within timeout limit. Some files may have been uploaded successfully. Stopped uploading on file (file name). That file and any after it in the zip were not added.
+	ErrMaxAllowedNumberOfFiles                            // You have reached the maximum allowed number of files/pages for this submission. Stopped uploading on file (file name). That file and any after it in the zip were not added.
+	ErrCouldNotUploadThumbnail                            // Could not upload the thumbnail (file name). Please try again. If the problem persists, contact an administrator.
+	ErrCouldNotCreateCopyOfFile                           // Could not create copy of that file in our system. Please try again. If the file is a PNG, make sure it is in RGB color mode and not Indexed color. If the problem persists, contact an administrator.
+	ErrCouldNotCreateThumbnail                            // Could not create a thumbnail for the file (file name). Thumbnail file was called (thumbnail file name). If the file is a PNG, make sure it is in RGB color mode and not Indexed color. Please try again. If the problem persists, contact an administrator.
+	ErrUserCanceled                                       // User canceled.
+	ErrInvalidProgressKey                                 // Invalid Progress Key.
+	ErrNoPermissionToDeleteSubmission                     // Current user does not have permission to delete this submission.
+	ErrNoPermissionToRemoveFile                           // Current user does not have permission to remove this file.
+	ErrNoPermissionToChangeOrderOfFile                    // Current user does not have permission to change the order of this file.
+	ErrSubmissionDeleted                                  // That submission has been deleted.
+	ErrTooManySubmissionIDsToQuery                        // Too many submission ids to query. Limit exceeded.
+	ErrNoPermissionToGetFavlist                           // Current user does not have permission to get the favlist of this submission.
+	ErrCouldNotCreateZIPtmpExtractionDir                  // Couldn't create zip tmp extraction dir.
+	ErrCouldNotRenameFileInUnzipProcess                   // Could not rename file in unzip process.
+	ErrInvalidKeywordID                                   // Invalid Keyword ID.
+	ErrCouldNotReplaceFileOrThumbnail                     // Could not replace that file or thumbnail.
+	ErrRequestNotSentInHTTPSMode         ErrorCode = 999  // Request was not sent in HTTPS mode.
 )
+
+var errorMessages = map[ErrorCode]string{
+	ErrInvalidLogin:                      "invalid login",
+	ErrEmptySessionID:                    "no session id sent",
+	ErrInvalidSessionID:                  "invalid session id",
+	ErrInvalidResultsID:                  "invalid results id",
+	ErrNoResultsFound:                    "no results found for results id",
+	ErrNoPermissionToUpload:              "no permission to upload files",
+	ErrHourlyLimitReached:                "submission hourly limit reached",
+	ErrDatabaseError:                     "database error",
+	ErrNoValidSubmissionID:               "no valid submission id given",
+	ErrNoPermissionToEditSubmission:      "no permission to edit this submission",
+	ErrNoPermissionToEditFile:            "no permission to edit this file",
+	ErrCouldNotCreateEntry:               "could not create an entry for the file",
+	ErrNoPermissionToBulkUpload:          "no permission to bulk upload",
+	ErrZIPFileTooBig:                     "zip file is too big",
+	ErrInvalidFileName:                   "file names cannot contain '..'",
+	ErrInvalidCharactersInFilenames:      "invalid characters in zip file names",
+	ErrCouldNotExtractFiles:              "could not extract any files from zip",
+	ErrNotZIPFile:                        "not a zip file",
+	ErrZIPUploadFailed:                   "zip upload failed",
+	ErrFileCouldNotBeRead:                "file could not be read",
+	ErrFileTooLarge:                      "file too large",
+	ErrUnsupportedFileType:               "unsupported file type",
+	ErrFileTooLargeInPixelSize:           "file too large in pixel size",
+	ErrFileNotInRGBOrGreyscale:           "file not in rgb or greyscale",
+	ErrUnknownErrorCheckingFile:          "unknown error checking file",
+	ErrUnsupportedThumbnailType:          "unsupported thumbnail type",
+	ErrThumbnailTooLargeInPixelSize:      "thumbnail too large in pixel size",
+	ErrThumbnailNotInRGBOrGreyscale:      "thumbnail not in rgb or greyscale",
+	ErrUnknownErrorCheckingThumbnail:     "unknown error checking thumbnail",
+	ErrTooManySubmissionIDs:              "maximum files per submission exceeded",
+	ErrCancellationRequest:               "upload cancelled or timed out",
+	ErrMaxAllowedNumberOfFiles:           "maximum allowed number of files reached",
+	ErrCouldNotUploadThumbnail:           "could not upload thumbnail",
+	ErrCouldNotCreateCopyOfFile:          "could not create copy of file",
+	ErrCouldNotCreateThumbnail:           "could not create thumbnail",
+	ErrUserCanceled:                      "user canceled",
+	ErrInvalidProgressKey:                "invalid progress key",
+	ErrNoPermissionToDeleteSubmission:    "no permission to delete this submission",
+	ErrNoPermissionToRemoveFile:          "no permission to remove this file",
+	ErrNoPermissionToChangeOrderOfFile:   "no permission to change the order of this file",
+	ErrSubmissionDeleted:                 "submission has been deleted",
+	ErrTooManySubmissionIDsToQuery:       "too many submission ids to query",
+	ErrNoPermissionToGetFavlist:          "no permission to get the favlist",
+	ErrCouldNotCreateZIPtmpExtractionDir: "could not create zip extraction dir",
+	ErrCouldNotRenameFileInUnzipProcess:  "could not rename file in unzip process",
+	ErrInvalidKeywordID:                  "invalid keyword id",
+	ErrCouldNotReplaceFileOrThumbnail:    "could not replace file or thumbnail",
+	ErrRequestNotSentInHTTPSMode:         "request was not sent in https mode",
+	ErrUnexpectedStatus:                  "unexpected status code",
+}
@@ -0,0 +1,86 @@
+package types_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestAPIError(t *testing.T) {
+	err := fmt.Errorf("searching: %w", &types.APIError{
+		Code:       types.ErrInvalidSessionID,
+		Message:    "Invalid Session ID sent as variable 'sid'.",
+		Endpoint:   "/api_search.php",
+		StatusCode: http.StatusOK,
+	})
+	if !errors.Is(err, types.ErrInvalidSessionID) {
+		t.Errorf("errors.Is(%v, ErrInvalidSessionID) = false, want true", err)
+	}
+	if errors.Is(err, types.ErrNoResultsFound) {
+		t.Errorf("errors.Is(%v, ErrNoResultsFound) = true, want false", err)
+	}
+	var apiErr *types.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("errors.As(%v, *APIError) = false, want true", err)
+	}
+	if apiErr.Endpoint != "/api_search.php" || apiErr.Code != types.ErrInvalidSessionID {
+		t.Errorf("got endpoint %q and code %d", apiErr.Endpoint, apiErr.Code)
+	}
+	var code types.ErrorCode
+	if !errors.As(err, &code) || code != types.ErrInvalidSessionID {
+		t.Errorf("errors.As(%v, ErrorCode) = %d, want %d", err, code, types.ErrInvalidSessionID)
+	}
+	if want := "/api_search.php: [2]: Invalid Session ID sent as variable 'sid'."; apiErr.Error() != want {
+		t.Errorf("got %q, want %q", apiErr.Error(), want)
+	}
+}
+
+func TestUnexpectedStatus(t *testing.T) {
+	err := &types.APIError{
+		Code:       types.ErrUnexpectedStatus,
+		Message:    "502 Bad Gateway",
+		Endpoint:   "/api_login.php",
+		StatusCode: http.StatusBadGateway,
+	}
+	if !errors.Is(err, types.ErrUnexpectedStatus) {
+		t.Errorf("errors.Is(%v, ErrUnexpectedStatus) = false, want true", err)
+	}
+	if want := "/api_login.php: unexpected status code 502 Bad Gateway (502)"; err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestTemporary(t *testing.T) {
+	for _, test := range []struct {
+		code   types.ErrorCode
+		status int
+		want   bool
+	}{
+		{types.ErrDatabaseError, http.StatusOK, true},
+		{types.ErrCouldNotCreateThumbnail, http.StatusOK, true},
+		{types.ErrInvalidSessionID, http.StatusOK, false},
+		{types.ErrHourlyLimitReached, http.StatusOK, false},
+		{types.ErrUnexpectedStatus, http.StatusInternalServerError, true},
+		{types.ErrUnexpectedStatus, http.StatusServiceUnavailable, true},
+		{types.ErrUnexpectedStatus, http.StatusTooManyRequests, true},
+		{types.ErrUnexpectedStatus, http.StatusNotFound, false},
+		{types.ErrUnexpectedStatus, http.StatusForbidden, false},
+	} {
+		err := &types.APIError{Code: test.code, StatusCode: test.status}
+		if got := err.Temporary(); got != test.want {
+			t.Errorf("code %d with status %d: got Temporary() %t, want %t", test.code, test.status, got, test.want)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	if got, want := types.ErrNoResultsFound.Error(), "[4]: no results found for results id"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := types.ErrorCode(500).Error(), "[500]: unknown error code"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -1,7 +1,12 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 // Ratings to use when calling Client.ChangeRatings
@@ -20,6 +25,10 @@ const (
 // A string 11111 means items of any rating would be shown. Only 'left-most significant bits' are returned. So 11010 and 1101 are the same, and 10000 and 1 are the same.
 // Ratings implements json.Unmarshaler which converts string or uint8 bitmask into Ratings.
 // It also implements fmt.Stringer which calls its String method, converting the values into an LSB bitmask.
+//
+// A field that is nil is unset, which is not the same as No. Unset fields are treated as No by Byte, String and the
+// set operations, but Ratings with every field unset are sent as no ratings at all, letting the API use its default.
+// ParseMask, ParseMaskU and ParseRatings always set every field.
 type Ratings struct {
 	General        *BooleanYN `json:"tag[1],omitempty" query:"tag[1]"` // Show images with Rating tag: General - Suitable for all ages.
 	Nudity         *BooleanYN `json:"tag[2],omitempty" query:"tag[2]"` // Show images with Rating tag: Nudity - Nonsexual nudity exposing breasts or genitals (must not show arousal).
@@ -28,15 +37,129 @@ type Ratings struct {
 	StrongViolence *BooleanYN `json:"tag[5],omitempty" query:"tag[5]"` // Show images with Rating tag: StrongViolence - Strong violence, blood, serious injury or death.
 }
 
+// UnmarshalJSON parses a bitmask sent as a string or a number. An empty string or null leaves every field unset.
 func (r *Ratings) UnmarshalJSON(b []byte) error {
-	*r = ParseMask(strings.Trim(string(b), `"`))
-	return nil
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*r = Ratings{}
+		return nil
+	}
+	return r.UnmarshalText([]byte(s))
+}
+
+// MarshalJSON returns the bitmask of String as a JSON string.
+func (r Ratings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
 }
 
+// MarshalText returns the bitmask of String.
 func (r Ratings) MarshalText() ([]byte, error) {
 	return []byte(r.String()), nil
 }
 
+// UnmarshalText parses a bitmask or a list of names with ParseRatings. Empty text leaves every field unset.
+func (r *Ratings) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = Ratings{}
+		return nil
+	}
+	ratings, err := ParseRatings(string(text))
+	if err != nil {
+		return err
+	}
+	*r = ratings
+	return nil
+}
+
+// ratingNames are the names of the fields of Ratings, from General to StrongViolence.
+var ratingNames = [...]string{"general", "nudity", "mildviolence", "sexual", "strongviolence"}
+
+// ratingAliases are the other names accepted by ParseRatings.
+var ratingAliases = map[string]string{
+	"mild_violence":   "mildviolence",
+	"violence":        "mildviolence",
+	"sexual_themes":   "sexual",
+	"sexualthemes":    "sexual",
+	"strong_violence": "strongviolence",
+}
+
+// ParseRatings returns the Ratings with the named fields set to Yes, and every other field set to No.
+// Names are separated by commas or spaces, are case-insensitive, and are the names of Names, e.g. "general,nudity,sexual".
+// A bitmask such as "11010" is also accepted, and an empty string sets every field to No.
+func ParseRatings(s string) (Ratings, error) {
+	s = strings.TrimSpace(s)
+	if s != "" && strings.Trim(s, "01") == "" {
+		if len(s) > len(ratingNames) {
+			return Ratings{}, fmt.Errorf("invalid ratings mask %q", s)
+		}
+		return ParseMask(s), nil
+	}
+	var mask uint8
+	for _, name := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		if alias, ok := ratingAliases[name]; ok {
+			name = alias
+		}
+		i := slices.Index(ratingNames[:], name)
+		if i < 0 {
+			return Ratings{}, fmt.Errorf("unknown rating %q", name)
+		}
+		mask |= General >> i
+	}
+	return ParseMaskU(mask), nil
+}
+
+// Names returns the names of the fields set to Yes, as accepted by ParseRatings.
+func (r Ratings) Names() []string {
+	var names []string
+	b := r.Byte()
+	for i, name := range ratingNames {
+		if b&(General>>i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// IsSet reports whether any field is set, even to No.
+func (r Ratings) IsSet() bool {
+	return r != Ratings{}
+}
+
+// Union returns the Ratings with the fields set to Yes in either r or other.
+// Fields that are unset in both stay unset.
+func (r Ratings) Union(other Ratings) Ratings {
+	return r.combine(other, r.Byte()|other.Byte())
+}
+
+// Intersect returns the Ratings with the fields set to Yes in both r and other.
+// Fields that are unset in both stay unset.
+func (r Ratings) Intersect(other Ratings) Ratings {
+	return r.combine(other, r.Byte()&other.Byte())
+}
+
+// IsSubset reports whether every field set to Yes in r is also set to Yes in other.
+func (r Ratings) IsSubset(other Ratings) bool {
+	return r.Byte()&^other.Byte() == 0
+}
+
+// combine returns the Ratings of mask, with the fields that are unset in both r and other left unset.
+func (r Ratings) combine(other Ratings, mask uint8) Ratings {
+	result := ParseMaskU(mask)
+	fields := []struct{ a, b, result **BooleanYN }{
+		{&r.General, &other.General, &result.General},
+		{&r.Nudity, &other.Nudity, &result.Nudity},
+		{&r.MildViolence, &other.MildViolence, &result.MildViolence},
+		{&r.Sexual, &other.Sexual, &result.Sexual},
+		{&r.StrongViolence, &other.StrongViolence, &result.StrongViolence},
+	}
+	for _, f := range fields {
+		if *f.a == nil && *f.b == nil {
+			*f.result = nil
+		}
+	}
+	return result
+}
+
 // ParseMaskU returns a Ratings based on a ratings bitmask. True is 1, false is 0
 //
 //	ratings := ParseMaskU(General|Nudity)
@@ -82,27 +205,21 @@ func ParseMask(s string) Ratings {
 // String 11111 means keywords of any rating would be shown.
 // Only 'left-most significant bits' need to be sent.
 // So 11010 and 1101 are the same, and 10000 and 1 are the same.
+//
+// Unset fields are 0. If every field is unset, String returns an empty string, and the API uses its default.
+// If every field is No, String returns "00000", since an empty mask would be read as General only.
 func (r Ratings) String() string {
-	// build a 5-byte buffer of '0' or '1'
-	var b [5]byte
-	b[0] = r.General.Byte()
-	b[1] = r.Nudity.Byte()
-	b[2] = r.MildViolence.Byte()
-	b[3] = r.Sexual.Byte()
-	b[4] = r.StrongViolence.Byte()
-
-	// trim trailing '0's by finding the last '1'
-	last := len(b) - 1
-	for last >= 0 && b[last] == '0' {
-		last--
-	}
-	if last < 0 {
+	if !r.IsSet() {
 		return ""
 	}
-	return string(b[:last+1])
+	mask := strings.TrimRight(fmt.Sprintf("%05b", r.Byte()), "0")
+	if mask == "" {
+		return "00000"
+	}
+	return mask
 }
 
-// Byte returns a 5-bit mask with General in the MSB and StrongViolence in the LSB.
+// Byte returns a 5-bit mask with General in the MSB and StrongViolence in the LSB. Fields that are not set are 0.
 //
 //	bit 4 ── General
 //	bit 3 ── Nudity
@@ -111,20 +228,129 @@ func (r Ratings) String() string {
 //	bit 0 ── StrongViolence
 func (r Ratings) Byte() byte {
 	var b byte
-	if r.General.Bool() {
+	if yes(r.General) {
 		b |= 1 << 4
 	}
-	if r.Nudity.Bool() {
+	if yes(r.Nudity) {
 		b |= 1 << 3
 	}
-	if r.MildViolence.Bool() {
+	if yes(r.MildViolence) {
 		b |= 1 << 2
 	}
-	if r.Sexual.Bool() {
+	if yes(r.Sexual) {
 		b |= 1 << 1
 	}
-	if r.StrongViolence.Bool() {
+	if yes(r.StrongViolence) {
 		b |= 1 << 0
 	}
 	return b
 }
+
+func yes(b *BooleanYN) bool {
+	return b != nil && bool(*b)
+}
+
+// ContentTag is a rating tag of a submission, as returned in SubmissionRating.ContentTagID.
+// Each ContentTag matches a field of Ratings.
+type ContentTag int
+
+const (
+	ContentTagGeneral        ContentTag = iota + 1 // 1 - General
+	ContentTagNudity                               // 2 - Nudity
+	ContentTagMildViolence                         // 3 - Mild Violence
+	ContentTagSexual                               // 4 - Sexual Themes
+	ContentTagStrongViolence                       // 5 - Strong Violence
+)
+
+func (t ContentTag) String() string {
+	switch t {
+	case ContentTagGeneral:
+		return "General"
+	case ContentTagNudity:
+		return "Nudity"
+	case ContentTagMildViolence:
+		return "Mild Violence"
+	case ContentTagSexual:
+		return "Sexual Themes"
+	case ContentTagStrongViolence:
+		return "Strong Violence"
+	}
+	return "ContentTag(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Mask returns the bit of the ContentTag in a ratings bitmask, e.g. General for ContentTagGeneral.
+// Unknown tags return 0.
+func (t ContentTag) Mask() uint8 {
+	if t < ContentTagGeneral || t > ContentTagStrongViolence {
+		return 0
+	}
+	return General >> (t - ContentTagGeneral)
+}
+
+// Rating is the overall rating of a submission, as returned in SubmissionBasic.RatingID.
+type Rating int
+
+const (
+	RatingGeneral Rating = iota // 0 - General
+	RatingMature                // 1 - Mature, for nudity or mild violence
+	RatingAdult                 // 2 - Adult, for sexual themes or strong violence
+)
+
+func (r Rating) String() string {
+	switch r {
+	case RatingGeneral:
+		return "General"
+	case RatingMature:
+		return "Mature"
+	case RatingAdult:
+		return "Adult"
+	}
+	return "Rating(" + strconv.Itoa(int(r)) + ")"
+}
+
+// RatingsOf returns the Ratings with the fields of the given tags set to Yes, and every other field set to No.
+// Without any tag, only General is set, as submissions without a rating tag are suitable for all ages.
+//
+//	ratings := RatingsOf(ContentTagNudity, ContentTagSexual) // "0101"
+func RatingsOf(tags ...ContentTag) Ratings {
+	var mask uint8
+	for _, tag := range tags {
+		mask |= tag.Mask()
+	}
+	if mask == 0 {
+		mask = General
+	}
+	return ParseMaskU(mask)
+}
+
+// Tags returns the ContentTag of every field set to Yes.
+func (r Ratings) Tags() []ContentTag {
+	var tags []ContentTag
+	b := r.Byte()
+	for tag := ContentTagGeneral; tag <= ContentTagStrongViolence; tag++ {
+		if b&tag.Mask() != 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Rated is implemented by values that have content ratings, such as submissions.
+// Ratings implements Rated by returning itself.
+type Rated interface {
+	ContentRatings() Ratings
+}
+
+func (r Ratings) ContentRatings() Ratings {
+	return r
+}
+
+// Allows reports whether every rating of rated is allowed by r, e.g. to check whether a submission
+// can be shown to a user with these allowed ratings. Fields of r that are not set are not allowed.
+//
+//	if user.Ratings.Allows(submission) {
+//		fmt.Println(submission.Title)
+//	}
+func (r Ratings) Allows(rated Rated) bool {
+	return rated.ContentRatings().IsSubset(r)
+}
@@ -0,0 +1,213 @@
+package types_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestParseRatings(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"general,nudity,sexual", "1101"},
+		{"General Sexual_Themes  strong_violence", "10011"},
+		{"violence", "001"},
+		{"11010", "1101"},
+		{"1", "1"},
+		{"00000", "00000"},
+		{"", "00000"},
+	} {
+		got, err := types.ParseRatings(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, got, test.want)
+		}
+		if got.General == nil || got.StrongViolence == nil {
+			t.Errorf("%q: got unset fields, want every field set", test.in)
+		}
+	}
+	for _, in := range []string{"general,cute", "110101", "2"} {
+		if _, err := types.ParseRatings(in); err == nil {
+			t.Errorf("%q: got no error", in)
+		}
+	}
+}
+
+func TestRatingsNames(t *testing.T) {
+	ratings := types.ParseMaskU(types.General | types.MildViolence | types.StrongViolence)
+	want := []string{"general", "mildviolence", "strongviolence"}
+	if got := ratings.Names(); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	parsed, err := types.ParseRatings(strings.Join(ratings.Names(), ","))
+	if err != nil || parsed.String() != ratings.String() {
+		t.Errorf("got %s, %v, want %s", parsed, err, ratings)
+	}
+	if got := (types.Ratings{}).Names(); got != nil {
+		t.Errorf("got %q for unset ratings, want none", got)
+	}
+}
+
+func TestRatingsSets(t *testing.T) {
+	general := types.ParseMaskU(types.General)
+	mature := types.ParseMaskU(types.General | types.Nudity | types.MildViolence)
+	adult := types.ParseMaskU(types.Sexual | types.StrongViolence)
+
+	if got := general.Union(adult).String(); got != "10011" {
+		t.Errorf("Union: got %q, want %q", got, "10011")
+	}
+	if got := mature.Intersect(general).String(); got != "1" {
+		t.Errorf("Intersect: got %q, want %q", got, "1")
+	}
+	if got := mature.Intersect(adult).String(); got != "00000" {
+		t.Errorf("Intersect: got %q, want %q", got, "00000")
+	}
+	if !general.IsSubset(mature) || mature.IsSubset(general) || adult.IsSubset(mature) {
+		t.Error("IsSubset: got wrong result")
+	}
+
+	// Fields unset in both stay unset.
+	nudity := types.Ratings{Nudity: (*types.BooleanYN)(types.Address(true))}
+	union := nudity.Union(types.Ratings{General: (*types.BooleanYN)(types.Address(false))})
+	if union.General == nil || union.Nudity == nil || union.MildViolence != nil || union.StrongViolence != nil {
+		t.Errorf("Union: got %+v, want only General and Nudity set", union)
+	}
+	if got := (types.Ratings{}).Union(types.Ratings{}); got.IsSet() {
+		t.Errorf("Union of unset ratings: got %s, want unset", got)
+	}
+}
+
+func TestRatingsString(t *testing.T) {
+	no := (*types.BooleanYN)(types.Address(false))
+	for _, test := range []struct {
+		name    string
+		ratings types.Ratings
+		want    string
+	}{
+		{"unset", types.Ratings{}, ""},
+		{"all no", types.ParseMaskU(0), "00000"},
+		{"one no", types.Ratings{Sexual: no}, "00000"},
+		{"general", types.ParseMaskU(types.General), "1"},
+		{"all", types.ParseMaskU(types.General | types.Nudity | types.MildViolence | types.Sexual | types.StrongViolence), "11111"},
+	} {
+		if got := test.ratings.String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRatingsMarshal(t *testing.T) {
+	for _, ratings := range []types.Ratings{
+		types.ParseMaskU(types.General | types.Sexual),
+		types.ParseMaskU(0),
+		types.ParseMaskU(types.StrongViolence),
+	} {
+		b, err := json.Marshal(ratings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got types.Ratings
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("%s: %v", b, err)
+		}
+		if got.String() != ratings.String() || !got.IsSet() {
+			t.Errorf("%s: got %s, want %s", b, got, ratings)
+		}
+
+		text, err := ratings.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = types.Ratings{}
+		if err := got.UnmarshalText(text); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if got.String() != ratings.String() {
+			t.Errorf("%s: got %s, want %s", text, got, ratings)
+		}
+	}
+
+	// Unset ratings are marshaled as an empty string and unmarshaled as unset.
+	b, err := json.Marshal(types.Ratings{})
+	if err != nil || string(b) != `""` {
+		t.Errorf(`got %s, %v, want ""`, b, err)
+	}
+	for _, in := range []string{`""`, `null`} {
+		got := types.ParseMaskU(types.General)
+		if err := json.Unmarshal([]byte(in), &got); err != nil || got.IsSet() {
+			t.Errorf("%s: got %+v, %v, want unset", in, got, err)
+		}
+	}
+	var got types.Ratings
+	if err := json.Unmarshal([]byte(`"general,nudity"`), &got); err != nil || got.String() != "11" {
+		t.Errorf("got %s, %v, want 11", got, err)
+	}
+	if err := json.Unmarshal([]byte(`11`), &got); err != nil || got.String() != "11" {
+		t.Errorf("got %s, %v, want 11 from a number", got, err)
+	}
+}
+
+func TestRatingsOf(t *testing.T) {
+	for _, test := range []struct {
+		tags []types.ContentTag
+		want string
+	}{
+		{nil, "1"},
+		{[]types.ContentTag{types.ContentTagNudity, types.ContentTagSexual}, "0101"},
+		{[]types.ContentTag{types.ContentTagGeneral, types.ContentTagStrongViolence}, "10001"},
+		{[]types.ContentTag{types.ContentTag(9)}, "1"},
+	} {
+		ratings := types.RatingsOf(test.tags...)
+		if got := ratings.String(); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.tags, got, test.want)
+		}
+		var want []types.ContentTag
+		for _, tag := range test.tags {
+			if tag.Mask() != 0 {
+				want = append(want, tag)
+			}
+		}
+		if want == nil {
+			want = []types.ContentTag{types.ContentTagGeneral}
+		}
+		if got := ratings.Tags(); !slices.Equal(got, want) {
+			t.Errorf("%v: got tags %v, want %v", test.tags, got, want)
+		}
+	}
+	if got := types.ContentTagMildViolence.String(); got != "Mild Violence" {
+		t.Errorf("got %q, want %q", got, "Mild Violence")
+	}
+	if got := types.ContentTag(9).String(); got != "ContentTag(9)" {
+		t.Errorf("got %q, want %q", got, "ContentTag(9)")
+	}
+}
+
+func TestRatingsAllows(t *testing.T) {
+	allowed := types.ParseMaskU(types.General | types.Nudity)
+	for _, test := range []struct {
+		rated types.Rated
+		want  bool
+	}{
+		{types.RatingsOf(), true},
+		{types.RatingsOf(types.ContentTagNudity), true},
+		{types.RatingsOf(types.ContentTagGeneral, types.ContentTagNudity), true},
+		{types.RatingsOf(types.ContentTagNudity, types.ContentTagSexual), false},
+		{types.RatingsOf(types.ContentTagStrongViolence), false},
+	} {
+		if got := allowed.Allows(test.rated); got != test.want {
+			t.Errorf("%s allows %s: got %t, want %t", allowed, test.rated.ContentRatings(), got, test.want)
+		}
+	}
+	// Unset ratings allow nothing.
+	if (types.Ratings{}).Allows(types.RatingsOf()) {
+		t.Error("unset ratings allow a general submission")
+	}
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the layout of the *_datetime fields returned by the API, e.g. "2024-01-02 15:04:05.123456+00".
+const DateLayout = "2006-01-02 15:04:05.999999-07"
+
+// dateLayoutMinutes is DateLayout for timezones with a minute offset, e.g. "2024-01-02 15:04:05.12+05:30".
+const dateLayoutMinutes = "2006-01-02 15:04:05.999999-07:00"
+
+// ParseTime parses a *_datetime field returned by the API, with an hour or hour and minute offset.
+// Dates in RFC 3339 are also accepted.
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range []string{DateLayout, dateLayoutMinutes, time.RFC3339Nano, time.DateTime} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// Time is a *_datetime field returned by the API, such as "2024-01-02 15:04:05.123456+00".
+// UnmarshalJSON accepts the formats of ParseTime, and an empty string or null as the zero Time.
+// The *_usertime fields are formatted for display in the timezone of the user, and are kept as strings.
+type Time struct {
+	time.Time
+}
+
+// MarshalJSON formats the Time in UTC with DateLayout, or as an empty string if it is zero.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return json.Marshal("")
+	}
+	return json.Marshal(t.UTC().Format(DateLayout))
+}
+
+// UnmarshalJSON parses a date string returned by the API.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil || *s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := ParseTime(*s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(DateLayout)
+}
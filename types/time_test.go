@@ -0,0 +1,59 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestParseTime(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-01-02 15:04:05.123456+00", time.Date(2024, 1, 2, 15, 4, 5, 123456000, time.UTC)},
+		{"2024-01-02 15:04:05+00", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2024-01-02 15:04:05.12-05", time.Date(2024, 1, 2, 20, 4, 5, 120000000, time.UTC)},
+		{"2024-01-02 15:04:05.12+05:30", time.Date(2024, 1, 2, 9, 34, 5, 120000000, time.UTC)},
+		{"2024-01-02 15:04:05-03:30", time.Date(2024, 1, 2, 18, 34, 5, 0, time.UTC)},
+		{"2024-01-02T15:04:05Z", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2024-01-02 15:04:05", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+	} {
+		got, err := types.ParseTime(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%q: got %s, want %s", test.in, got.UTC(), test.want)
+		}
+	}
+
+	for _, in := range []string{"", "yesterday", "2024-01-02 15:04:05+5:3"} {
+		if _, err := types.ParseTime(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestTimeJSON(t *testing.T) {
+	var v struct {
+		Date types.Time `json:"date"`
+	}
+	if err := json.Unmarshal([]byte(`{"date":"2024-01-02 15:04:05.12+05:30"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"date":"2024-01-02 09:34:05.12+00"}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"date":""}`), &v); err != nil || !v.Date.IsZero() {
+		t.Errorf("empty date: got %v, %v", v.Date, err)
+	}
+}
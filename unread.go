@@ -0,0 +1,69 @@
+package inkbunny
+
+import (
+	"context"
+	"iter"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// Unread returns a sequence of the unread submissions of the User, across all pages.
+// order is usually types.OrderByUnreadDatetime for the newest first, or types.OrderByUnreadDatetimeReverse for the oldest first.
+// An empty order defaults to the newest first.
+//
+//	for submission, err := range user.Unread(types.OrderByUnreadDatetimeReverse) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(submission.UnreadDateSystem, submission.Title)
+//	}
+//
+// The sequence stops after the last submission or the first error.
+func (u *User) Unread(order types.OrderBy) iter.Seq2[SubmissionSearch, error] {
+	return u.UnreadContext(u.Client().Context(), order)
+}
+
+// UnreadContext is like User.Unread but uses ctx for the requests.
+func (u *User) UnreadContext(ctx context.Context, order types.OrderBy) iter.Seq2[SubmissionSearch, error] {
+	return func(yield func(SubmissionSearch, error) bool) {
+		if order == "" {
+			order = types.OrderByUnreadDatetime
+		}
+		response, err := u.SearchSubmissionsContext(ctx, SubmissionSearchRequest{
+			UnreadSubmissions:  types.Yes,
+			OrderBy:            order,
+			SubmissionsPerPage: 100,
+			GetRID:             types.Yes,
+		})
+		if err != nil {
+			yield(SubmissionSearch{}, err)
+			return
+		}
+		for submission, err := range response.Paginate().SubmissionsContext(ctx) {
+			if err != nil {
+				yield(SubmissionSearch{}, err)
+				return
+			}
+			if !yield(submission, nil) {
+				return
+			}
+		}
+	}
+}
+
+// UnreadCount returns the number of unread submissions of the User, without returning the submissions.
+func (u *User) UnreadCount() (int, error) {
+	return u.UnreadCountContext(u.Client().Context())
+}
+
+// UnreadCountContext is like User.UnreadCount but uses ctx for the request.
+func (u *User) UnreadCountContext(ctx context.Context) (int, error) {
+	response, err := u.SearchSubmissionsContext(ctx, SubmissionSearchRequest{
+		UnreadSubmissions: types.Yes,
+		NoSubmissions:     types.Yes,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(response.ResultsCountAll), nil
+}
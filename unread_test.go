@@ -0,0 +1,77 @@
+package inkbunny_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+func TestUnread(t *testing.T) {
+	const unread = 250
+	server, ids := newServer(t, unread+10)
+	// Submissions are marked unread a second apart from the oldest, so the newest unread are in the order of ids.
+	now := time.Now().Add(-time.Hour)
+	server.Now = func() time.Time { return now }
+	for _, id := range slices.Backward(ids[:unread]) {
+		now = now.Add(time.Second)
+		server.MarkUnread("artist", id)
+	}
+	newest := ids[:unread]
+	oldest := slices.Clone(newest)
+	slices.Reverse(oldest)
+	user := login(t, server.Client())
+
+	count, err := user.UnreadCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != unread {
+		t.Errorf("got %d unread submissions, want %d", count, unread)
+	}
+
+	for _, test := range []struct {
+		order types.OrderBy
+		want  []types.IntString
+	}{
+		{"", newest},
+		{types.OrderByUnreadDatetimeReverse, oldest},
+	} {
+		before := server.Calls("search")
+		var got []types.IntString
+		for submission, err := range user.Unread(test.order) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if submission.UnreadDateSystem.IsZero() {
+				t.Errorf("submission %d has no unread date", submission.SubmissionID)
+			}
+			got = append(got, submission.SubmissionID)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("order %q: got %v, want %v", test.order, got, test.want)
+		}
+		if calls := server.Calls("search") - before; calls != 3 {
+			t.Errorf("order %q: got %d calls to search, want 3", test.order, calls)
+		}
+	}
+
+	before := server.Calls("search")
+	var got []types.IntString
+	for submission, err := range user.Unread("") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, submission.SubmissionID)
+		if len(got) == 120 {
+			break
+		}
+	}
+	if !slices.Equal(got, newest[:120]) {
+		t.Errorf("got %v, want %v", got, newest[:120])
+	}
+	if calls := server.Calls("search") - before; calls != 2 {
+		t.Errorf("got %d calls to search after breaking on the second page, want 2", calls)
+	}
+}
@@ -103,15 +103,30 @@ func (u *UploadStatus) UnmarshalJSON(data []byte) error {
 
 // Upload uploads one or more files (and optional thumbnails) to an Inkbunny submission.
 // If multiple FileUpload entries are provided, each is sent in sequence, using the returned submission_id from the previous upload for subsequent calls.
+// When the User has Credentials, an upload of a single file or a single zip file whose files implement io.Seeker
+// is replayed once after an expired session. Uploads of several files are never replayed.
 // URL: https://inkbunny.net/api_upload.php
 func (u *User) Upload(req UploadRequest) (UploadResponse, error) {
 	if req.SID == "" {
-		if u.SID == "" {
+		if u.sid() == "" {
 			return UploadResponse{}, ErrNotLoggedIn
 		}
-		req.SID = u.SID
+		req.SID = u.sid()
 	}
-	return u.Client().Upload(req)
+	if !req.replayable() {
+		return u.Client().Upload(req)
+	}
+	replay := false
+	return withSession(cmp.Or(req.Context, u.Client().Context()), u, req.SID, func(sid string) (UploadResponse, error) {
+		if replay {
+			if err := req.rewind(); err != nil {
+				return UploadResponse{}, err
+			}
+		}
+		replay = true
+		req.SID = sid
+		return u.Client().Upload(req)
+	})
 }
 
 func (c *Client) Upload(req UploadRequest) (UploadResponse, error) {
@@ -174,13 +189,18 @@ func Upload(req UploadRequest) (UploadResponse, error) {
 }
 
 func (u *UploadResponse) Delete() error {
+	return u.DeleteContext(u.client.Get().Context())
+}
+
+// DeleteContext is like UploadResponse.Delete but uses ctx for the request.
+func (u *UploadResponse) DeleteContext(ctx context.Context) error {
 	if u.SID == "" {
 		return ErrEmptySID
 	}
 	if u.SubmissionID == "" {
 		return ErrEmptySubmissionID
 	}
-	response, err := PostDecode[DeleteSubmissionResponse](u.client.Get(), ApiUrl("delsubmission"), url.Values{"sid": {u.SID}, "submission_id": {u.SubmissionID}})
+	response, err := PostDecodeContext[DeleteSubmissionResponse](ctx, u.client.Get(), u.client.ApiUrl("delsubmission"), url.Values{"sid": {u.SID}, "submission_id": {u.SubmissionID}})
 	if err != nil {
 		return err
 	}
@@ -207,7 +227,7 @@ func (u *UploadResponse) Cancel() error {
 // Assumes that there are no thumbnails, otherwise use uploadSingle.
 // URL: https://inkbunny.net/api_upload.php
 func uploadMultiple(c *Client, r UploadRequest) (UploadResponse, error) {
-	endpoint := ApiUrl("upload")
+	endpoint := c.ApiUrl("upload")
 
 	pipeReader, pipeWriter := io.Pipe()
 	defer pipeReader.Close()
@@ -236,13 +256,13 @@ func uploadMultiple(c *Client, r UploadRequest) (UploadResponse, error) {
 		lastErr = w.Close()
 	}()
 
-	req, err := http.NewRequestWithContext(cmp.Or(r.Context, c.ctx), http.MethodPost, endpoint.String(), pipeReader)
+	req, err := http.NewRequestWithContext(cmp.Or(r.Context, c.Context()), http.MethodPost, endpoint.String(), pipeReader)
 	if err != nil {
 		return UploadResponse{}, err
 	}
 
 	req.Header.Set("Content-Type", w.FormDataContentType())
-	httpResp, err := c.client.Do(req)
+	httpResp, err := c.Do(req)
 	if err != nil {
 		return UploadResponse{}, err
 	}
@@ -256,7 +276,7 @@ func uploadMultiple(c *Client, r UploadRequest) (UploadResponse, error) {
 
 // uploadZip performs the actual multipart/form-data POST for a UploadRequest.ZipFile.
 func uploadZip(c *Client, r UploadRequest) (UploadResponse, error) {
-	endpoint := ApiUrl("upload")
+	endpoint := c.ApiUrl("upload")
 
 	pipeReader, pipeWriter := io.Pipe()
 	defer pipeReader.Close()
@@ -283,13 +303,13 @@ func uploadZip(c *Client, r UploadRequest) (UploadResponse, error) {
 		lastErr = w.Close()
 	}()
 
-	req, err := http.NewRequestWithContext(cmp.Or(r.Context, c.ctx), http.MethodPost, endpoint.String(), pipeReader)
+	req, err := http.NewRequestWithContext(cmp.Or(r.Context, c.Context()), http.MethodPost, endpoint.String(), pipeReader)
 	if err != nil {
 		return UploadResponse{}, err
 	}
 
 	req.Header.Set("Content-Type", w.FormDataContentType())
-	httpResp, err := c.client.Do(req)
+	httpResp, err := c.Do(req)
 	if err != nil {
 		return UploadResponse{}, err
 	}
@@ -304,7 +324,7 @@ func uploadZip(c *Client, r UploadRequest) (UploadResponse, error) {
 
 // uploadSingle performs the actual multipart/form-data POST for a single FileUpload.
 func uploadSingle(c *Client, r UploadRequest, index int) (UploadResponse, error) {
-	endpoint := ApiUrl("upload")
+	endpoint := c.ApiUrl("upload")
 
 	pipeReader, pipeWriter := io.Pipe()
 	defer pipeReader.Close()
@@ -347,13 +367,13 @@ func uploadSingle(c *Client, r UploadRequest, index int) (UploadResponse, error)
 		lastErr = w.Close()
 	}()
 
-	req, err := http.NewRequestWithContext(cmp.Or(r.Context, c.ctx), http.MethodPost, endpoint.String(), pipeReader)
+	req, err := http.NewRequestWithContext(cmp.Or(r.Context, c.Context()), http.MethodPost, endpoint.String(), pipeReader)
 	if err != nil {
 		return UploadResponse{}, err
 	}
 
 	req.Header.Set("Content-Type", w.FormDataContentType())
-	httpResp, err := c.client.Do(req)
+	httpResp, err := c.Do(req)
 	if err != nil {
 		return UploadResponse{}, err
 	}
@@ -374,7 +394,7 @@ func UploadProgress(c *Client, progressKey string, cancel bool) (UploadProgressR
 	if cancel {
 		values.Set("cancel", "yes")
 	}
-	httpResp, err := c.PostForm(ApiUrl("progress"), values)
+	httpResp, err := c.PostForm(c.ApiUrl("progress"), values)
 	if err != nil {
 		return UploadProgressResponse{}, err
 	}
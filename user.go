@@ -1,6 +1,7 @@
 package inkbunny
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
@@ -11,6 +12,7 @@ import (
 
 type User struct {
 	client   *Client
+	session  *session
 	SID      string          `json:"sid" query:"sid"`
 	Username string          `json:"username,omitempty" query:"username"`
 	UserID   types.IntString `json:"user_id,omitempty" query:"user_id"`
@@ -37,14 +39,24 @@ func Login(username, password string) (*User, error) {
 	return DefaultClient.Login(username, password)
 }
 
+// LoginContext is like Login but uses ctx for the request.
+func LoginContext(ctx context.Context, username, password string) (*User, error) {
+	return DefaultClient.LoginContext(ctx, username, password)
+}
+
 func (c *Client) Login(username, password string) (*User, error) {
+	return c.LoginContext(c.Context(), username, password)
+}
+
+// LoginContext is like Client.Login but uses ctx for the request.
+func (c *Client) LoginContext(ctx context.Context, username, password string) (*User, error) {
 	if username == "" {
 		return nil, ErrNilUser
 	}
 	if username != "guest" && password == "" {
 		return nil, ErrEmptyPassword
 	}
-	response, err := PostDecode[User](c, ApiUrl("login"), url.Values{"username": {username}, "password": {password}})
+	response, err := PostDecodeContext[User](ctx, c, c.ApiUrl("login"), url.Values{"username": {username}, "password": {password}})
 	if err != nil {
 		return nil, fmt.Errorf("error logging in: %w", err)
 	}
@@ -53,6 +65,9 @@ func (c *Client) Login(username, password string) (*User, error) {
 	}
 	response.Username = username
 	response.client = c
+	if c.credentials != nil {
+		response.session = &session{credentials: c.credentials}
+	}
 	return &response, nil
 }
 
@@ -60,20 +75,31 @@ func (u *User) Logout() error {
 	if u == nil {
 		return ErrNilUser
 	}
-	if u.SID == "" {
+	return u.LogoutContext(u.Client().Context())
+}
+
+// LogoutContext is like User.Logout but uses ctx for the request.
+func (u *User) LogoutContext(ctx context.Context) error {
+	if u == nil {
+		return ErrNilUser
+	}
+	sid := u.sid()
+	if sid == "" {
 		return ErrNotLoggedIn
 	}
-	response, err := PostDecode[types.LogoutResponse](u.Client(), ApiUrl("logout"), url.Values{"sid": {u.SID}})
+	response, err := PostDecodeContext[types.LogoutResponse](ctx, u.Client(), u.Client().ApiUrl("logout"), url.Values{"sid": {sid}})
 	if err != nil {
 		return fmt.Errorf("error logging out: %w", err)
 	}
 	if response.Logout != "success" {
 		return fmt.Errorf("logout failed, unexpected response: %s", response.Logout)
 	}
-	if response.SID != u.SID {
+	if response.SID != sid {
 		return ErrUnexpectedSID
 	}
-	*u = User{client: u.client}
+	u.locked(func() {
+		u.SID, u.Username, u.UserID, u.Ratings = "", "", 0, types.Ratings{}
+	})
 	return nil
 }
 
@@ -86,23 +112,48 @@ func (u *User) Logout() error {
 //     However, when calling this script, that tag will be set to "off"
 //     unless you explicitly keep it activated with the parameter Ratings{MildViolence: true}.
 //
-// You can also call types.ParseMaskU if you want to use a bitmask.
+// Unset fields are sent as No, so every tag is set explicitly, and User.Ratings has every field set afterward.
+// Like other requests, it is replayed once after an expired session when the User has Credentials.
+//
+// You can also call types.ParseMaskU if you want to use a bitmask, or types.ParseRatings to use names.
 func (u *User) ChangeRatings(ratings types.Ratings) error {
 	if u == nil {
 		return ErrNilUser
 	}
-	if u.SID == "" {
+	return u.ChangeRatingsContext(u.Client().Context(), ratings)
+}
+
+// ChangeRatingsContext is like User.ChangeRatings but uses ctx for the request.
+func (u *User) ChangeRatingsContext(ctx context.Context, ratings types.Ratings) error {
+	if u == nil {
+		return ErrNilUser
+	}
+	sid := u.sid()
+	if sid == "" {
 		return ErrNotLoggedIn
 	}
+	changed, err := withSession(ctx, u, sid, func(sid string) (types.Ratings, error) {
+		return u.changeRatings(ctx, sid, ratings)
+	})
+	if err != nil {
+		return err
+	}
+	u.locked(func() { u.Ratings = changed })
+	return nil
+}
+
+// changeRatings changes the ratings of the session sid, and returns them with every field set.
+// It does not update the User, so that relogin can call it while holding the session lock.
+func (u *User) changeRatings(ctx context.Context, sid string, ratings types.Ratings) (types.Ratings, error) {
+	ratings = types.ParseMaskU(ratings.Byte())
 	values := utils.StructToUrlValues(ratings)
-	values.Set("sid", u.SID)
-	response, err := PostDecode[User](u.Client(), ApiUrl("userrating"), values)
+	values.Set("sid", sid)
+	response, err := PostDecodeContext[User](ctx, u.Client(), u.Client().ApiUrl("userrating"), values)
 	if err != nil {
-		return fmt.Errorf("error changing ratings: %w", err)
+		return ratings, fmt.Errorf("error changing ratings: %w", err)
 	}
-	if response.SID != u.SID {
-		return ErrUnexpectedSID
+	if response.SID != sid {
+		return ratings, ErrUnexpectedSID
 	}
-	u.Ratings = ratings
-	return nil
+	return ratings, nil
 }
@@ -3,9 +3,14 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/ellypaws/inkbunny/types"
 )
@@ -13,14 +18,41 @@ import (
 // ParseResponse parses the HTTP response and returns the decoded value of type T.
 // It checks [http.Response.StatusCode], decodes and checks if the [http.Response.Body]
 // decodes into types.ErrorResponse, and finally decodes into T if no errors are returned.
+// API errors are returned as a *types.APIError, which can be matched using errors.Is with a types.ErrorCode.
 // ParseResponse also calls [io.Closer.Close] on the Body.
+//
+// When T is a struct without its own UnmarshalJSON, the Body is decoded in a single pass,
+// with error_code and error_message decoded alongside the fields of T, without reading the whole Body into memory.
 func ParseResponse[T any](response *http.Response) (T, error) {
 	var t T
 	defer response.Body.Close()
+
 	if response.StatusCode != http.StatusOK {
-		return t, fmt.Errorf("unexpected status code %s (%d)", response.Status, response.StatusCode)
+		return t, statusError(response)
 	}
 
+	envelope, ok := envelopeOf(reflect.TypeFor[T]())
+	if !ok {
+		return parseBuffered[T](response)
+	}
+	v := reflect.New(envelope)
+	if err := json.NewDecoder(response.Body).Decode(v.Interface()); err != nil {
+		return t, err
+	}
+	if code := v.Elem().FieldByName("ErrorCode").Interface().(*int); code != nil {
+		return t, &types.APIError{
+			Code:       types.ErrorCode(*code),
+			Message:    v.Elem().FieldByName("ErrorMessage").String(),
+			Endpoint:   endpoint(response),
+			StatusCode: response.StatusCode,
+		}
+	}
+	return v.Elem().FieldByName("Value").Interface().(T), nil
+}
+
+// parseBuffered reads the whole body, and decodes it as a types.ErrorResponse and then as T.
+func parseBuffered[T any](response *http.Response) (T, error) {
+	var t T
 	bin, err := io.ReadAll(response.Body)
 	if err != nil {
 		return t, err
@@ -32,12 +64,192 @@ func ParseResponse[T any](response *http.Response) (T, error) {
 	}
 
 	if errResponse.Code != nil {
-		return t, fmt.Errorf("[%d]: %s", *errResponse.Code, errResponse.Message)
+		return t, &types.APIError{
+			Code:       types.ErrorCode(*errResponse.Code),
+			Message:    errResponse.Message,
+			Endpoint:   endpoint(response),
+			StatusCode: response.StatusCode,
+		}
 	}
 
 	return DecodeBytes[T](bin)
 }
 
+// statusError returns the error of a response that does not have a 200 status.
+// The error_code and error_message of the body are used if present.
+func statusError(response *http.Response) error {
+	apiErr := &types.APIError{
+		Code:       types.ErrUnexpectedStatus,
+		Message:    response.Status,
+		Endpoint:   endpoint(response),
+		StatusCode: response.StatusCode,
+	}
+	bin, err := io.ReadAll(response.Body)
+	if err != nil {
+		return apiErr
+	}
+	if errResponse, err := DecodeBytes[types.ErrorResponse](bin); err == nil && errResponse.Code != nil {
+		apiErr.Code = types.ErrorCode(*errResponse.Code)
+		apiErr.Message = errResponse.Message
+	}
+	return apiErr
+}
+
+var (
+	envelopes   sync.Map // map[reflect.Type]reflect.Type, nil if the type cannot be wrapped
+	unmarshaler = reflect.TypeFor[json.Unmarshaler]()
+)
+
+// envelopeOf returns a struct type that embeds t along with the error_code and error_message fields,
+// so that both are decoded at once. It reports false if t is not a struct, has its own UnmarshalJSON,
+// or already has error fields.
+func envelopeOf(t reflect.Type) (reflect.Type, bool) {
+	if cached, ok := envelopes.Load(t); ok {
+		envelope, _ := cached.(reflect.Type)
+		return envelope, envelope != nil
+	}
+	envelope := buildEnvelope(t)
+	envelopes.Store(t, envelope)
+	return envelope, envelope != nil
+}
+
+func buildEnvelope(t reflect.Type) (envelope reflect.Type) {
+	if t.Kind() != reflect.Struct || t.Implements(unmarshaler) || reflect.PointerTo(t).Implements(unmarshaler) {
+		return nil
+	}
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "error_code" || name == "error_message" {
+			return nil
+		}
+	}
+	// StructOf panics for some embedded types with methods, which are then decoded with parseBuffered.
+	defer func() {
+		if recover() != nil {
+			envelope = nil
+		}
+	}()
+	return reflect.StructOf([]reflect.StructField{
+		{Name: "Value", Type: t, Anonymous: true},
+		{Name: "ErrorCode", Type: reflect.TypeFor[*int](), Tag: `json:"error_code"`},
+		{Name: "ErrorMessage", Type: reflect.TypeFor[string](), Tag: `json:"error_message"`},
+	})
+}
+
+// ParseResponseArray returns a sequence of the elements of the array under key in the top-level object of the
+// response, decoded one at a time without reading the whole [http.Response.Body] into memory.
+// Errors are returned like ParseResponse, and the sequence stops after the first error.
+// The Body is closed when the sequence ends.
+//
+//	for submission, err := range ParseResponseArray[SubmissionDetails](response, "submissions") {
+func ParseResponseArray[T any](response *http.Response, key string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			var t T
+			yield(t, statusError(response))
+			return
+		}
+		for v, err := range DecodeArray[T](response.Body, key) {
+			var apiErr *types.APIError
+			if errors.As(err, &apiErr) {
+				apiErr.Endpoint = endpoint(response)
+				apiErr.StatusCode = response.StatusCode
+			}
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// DecodeArray returns a sequence of the elements of the array under key in the top-level JSON object of body,
+// decoded one at a time. The other fields of the object are skipped.
+// If the object has an error_code, it is returned as a *types.APIError.
+// The sequence stops after the first error.
+func DecodeArray[T any](body io.Reader, key string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var t T
+		d := json.NewDecoder(body)
+		if err := expectDelim(d, '{'); err != nil {
+			yield(t, err)
+			return
+		}
+		var errResponse types.ErrorResponse
+		for d.More() {
+			token, err := d.Token()
+			if err != nil {
+				yield(t, err)
+				return
+			}
+			var skip json.RawMessage
+			switch token {
+			case "error_code":
+				err = d.Decode(&errResponse.Code)
+			case "error_message":
+				err = d.Decode(&errResponse.Message)
+			case key:
+				if errResponse.Code != nil {
+					err = d.Decode(&skip)
+					break
+				}
+				if err = expectDelim(d, '['); errors.Is(err, errNull) {
+					err = nil
+					break
+				}
+				if err != nil {
+					break
+				}
+				for d.More() {
+					var v T
+					if err := d.Decode(&v); err != nil {
+						yield(t, err)
+						return
+					}
+					if !yield(v, nil) {
+						return
+					}
+				}
+				err = expectDelim(d, ']')
+			default:
+				err = d.Decode(&skip)
+			}
+			if err != nil {
+				yield(t, err)
+				return
+			}
+		}
+		if errResponse.Code != nil {
+			yield(t, &types.APIError{Code: types.ErrorCode(*errResponse.Code), Message: errResponse.Message})
+		}
+	}
+}
+
+var errNull = errors.New("unexpected null")
+
+// expectDelim reads the next token and checks that it is delim. A null token returns errNull.
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return errNull
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// endpoint returns the request path of the response, if available.
+func endpoint(response *http.Response) string {
+	if response.Request == nil || response.Request.URL == nil {
+		return ""
+	}
+	return response.Request.URL.Path
+}
+
 func Decode[T any](body io.Reader) (T, error) {
 	d := json.NewDecoder(body)
 	var v T
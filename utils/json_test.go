@@ -0,0 +1,155 @@
+package utils_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny/types"
+	"github.com/ellypaws/inkbunny/utils"
+)
+
+func response(status int, body string) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    &http.Request{URL: &url.URL{Path: "/api_test.php"}},
+	}
+}
+
+type named struct {
+	Name string `json:"name"`
+}
+
+// withCode has its own error_code field, so it cannot be decoded in a single pass.
+type withCode struct {
+	Code *int   `json:"error_code"`
+	Name string `json:"name"`
+}
+
+// withFieldNames has fields with the names of the fields added to decode it in a single pass.
+type withFieldNames struct {
+	Value        string `json:"value"`
+	ErrorCode    int    `json:"code"`
+	ErrorMessage string `json:"message"`
+}
+
+// located embeds a type that reflect.StructOf cannot embed, so it is decoded like withCode.
+type located struct {
+	*time.Location
+	Name string `json:"name"`
+}
+
+func parse[T any](response *http.Response) (any, error) {
+	return utils.ParseResponse[T](response)
+}
+
+func TestParseResponse(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		status int
+		body   string
+		parse  func(*http.Response) (any, error)
+		want   any
+		err    error
+	}{
+		{"struct", http.StatusOK, `{"name":"fox"}`, parse[named], named{Name: "fox"}, nil},
+		{"struct error", http.StatusOK, `{"error_code":2,"error_message":"Invalid Session ID"}`, parse[named], nil, types.ErrInvalidSessionID},
+		{"error code 0", http.StatusOK, `{"error_code":0,"error_message":"Invalid login"}`, parse[named], nil, types.ErrInvalidLogin},
+		{"own error_code", http.StatusOK, `{"name":"fox"}`, parse[withCode], withCode{Name: "fox"}, nil},
+		{"own error_code error", http.StatusOK, `{"error_code":4,"error_message":"No results"}`, parse[withCode], nil, types.ErrNoResultsFound},
+		{"field names", http.StatusOK, `{"value":"v","code":3,"message":"m"}`, parse[withFieldNames], withFieldNames{Value: "v", ErrorCode: 3, ErrorMessage: "m"}, nil},
+		{"field names error", http.StatusOK, `{"value":"v","error_code":2,"error_message":"m"}`, parse[withFieldNames], nil, types.ErrInvalidSessionID},
+		{"StructOf panics", http.StatusOK, `{"name":"fox"}`, parse[located], located{Name: "fox"}, nil},
+		{"StructOf panics error", http.StatusOK, `{"error_code":2,"error_message":"m"}`, parse[located], nil, types.ErrInvalidSessionID},
+		{"map", http.StatusOK, `{"name":"fox"}`, parse[map[string]string], map[string]string{"name": "fox"}, nil},
+		{"map error", http.StatusOK, `{"error_code":2,"error_message":"m"}`, parse[map[string]string], nil, types.ErrInvalidSessionID},
+		{"status", http.StatusBadGateway, `<html>`, parse[named], nil, types.ErrUnexpectedStatus},
+		{"status with error code", http.StatusForbidden, `{"error_code":2,"error_message":"m"}`, parse[named], nil, types.ErrInvalidSessionID},
+	} {
+		got, err := test.parse(response(test.status, test.body))
+		if test.err != nil {
+			var apiErr *types.APIError
+			if !errors.Is(err, test.err) || !errors.As(err, &apiErr) {
+				t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+				continue
+			}
+			if apiErr.Endpoint != "/api_test.php" || apiErr.StatusCode != test.status {
+				t.Errorf("%s: got endpoint %q and status %d", test.name, apiErr.Endpoint, apiErr.StatusCode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseResponseArray(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		status int
+		body   string
+		want   []string
+		err    error
+	}{
+		{"array", http.StatusOK, `{"sid":"x","submissions":[{"name":"a"},{"name":"b"}],"pages":{"count":1}}`, []string{"a", "b"}, nil},
+		{"null", http.StatusOK, `{"submissions":null}`, nil, nil},
+		{"missing", http.StatusOK, `{"sid":"x"}`, nil, nil},
+		{"error before", http.StatusOK, `{"error_code":2,"error_message":"m","submissions":[{"name":"a"}]}`, nil, types.ErrInvalidSessionID},
+		{"error after", http.StatusOK, `{"submissions":[],"error_code":2,"error_message":"m"}`, nil, types.ErrInvalidSessionID},
+		{"status", http.StatusServiceUnavailable, ``, nil, types.ErrUnexpectedStatus},
+		{"not an array", http.StatusOK, `{"submissions":{}}`, nil, errors.New("expected [, got {")},
+	} {
+		var got []string
+		var err error
+		for v, e := range utils.ParseResponseArray[named](response(test.status, test.body), "submissions") {
+			if e != nil {
+				err = e
+				continue
+			}
+			got = append(got, v.Name)
+		}
+		switch {
+		case test.err == nil && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.err != nil && err == nil:
+			t.Errorf("%s: got no error, want %v", test.name, test.err)
+		case test.err != nil && !errors.Is(err, test.err) && err.Error() != test.err.Error():
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+		}
+		var apiErr *types.APIError
+		if errors.As(err, &apiErr) && apiErr.Endpoint != "/api_test.php" {
+			t.Errorf("%s: got endpoint %q", test.name, apiErr.Endpoint)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDecodeArrayBreak(t *testing.T) {
+	var got []string
+	for v, err := range utils.DecodeArray[named](strings.NewReader(`{"submissions":[{"name":"a"},{"name":"b"},{"name":"c"}]}`), "submissions") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.Name)
+		if len(got) == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("got %q, want [a b]", got)
+	}
+}
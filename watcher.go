@@ -0,0 +1,381 @@
+package inkbunny
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// WatchState is the high-water mark of a Watcher, saved in a StateStore between polls.
+type WatchState struct {
+	// SubmissionID is the highest submission ID that was returned by the search.
+	SubmissionID types.IntString `json:"submission_id"`
+	// CreateDate is the create_datetime of the newest submission that was returned by the search.
+	CreateDate types.Time `json:"create_datetime,omitzero"`
+	// Seen are the IDs of the newest submissions of the last poll, newest first.
+	// They are used to detect submissions that were added below the high-water mark, e.g. when made public late.
+	Seen []types.IntString `json:"seen,omitempty"`
+	// Polled is when the search last ran successfully.
+	Polled time.Time `json:"polled"`
+}
+
+// StateStore saves the WatchState of Watchers. A missing state is returned as the zero WatchState.
+type StateStore interface {
+	Load(ctx context.Context, key string) (WatchState, error)
+	Save(ctx context.Context, key string, state WatchState) error
+}
+
+// MemoryStore is a StateStore that keeps states in memory. It is safe for concurrent use.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]WatchState
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]WatchState)}
+}
+
+func (s *MemoryStore) Load(_ context.Context, key string) (WatchState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[key], nil
+}
+
+func (s *MemoryStore) Save(_ context.Context, key string, state WatchState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = state
+	return nil
+}
+
+// FileStore is a StateStore that keeps every state in a single JSON file, so that Watchers resume after a restart.
+// It is safe for concurrent use within a process.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore returns a FileStore for the file at path. The file is created on the first Save.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load(_ context.Context, key string) (WatchState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.read()
+	return states[key], err
+}
+
+func (s *FileStore) Save(_ context.Context, key string, state WatchState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.read()
+	if err != nil {
+		return err
+	}
+	states[key] = state
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := s.path + ".tmp"
+	if err := os.WriteFile(temp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temp, s.path)
+}
+
+func (s *FileStore) read() (map[string]WatchState, error) {
+	states := make(map[string]WatchState)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("error decoding watch states %s: %w", s.path, err)
+	}
+	return states, nil
+}
+
+// watchWindow is the number of submission IDs kept in WatchState.Seen.
+const watchWindow = 500
+
+// Watcher periodically runs a submission search ordered by create_datetime, and returns only the submissions
+// that are new since the last poll. Use User.NewWatcher or Client.NewWatcher to create one.
+//
+//	watcher := user.NewWatcher(inkbunny.SubmissionSearchRequest{Text: "fox"}).
+//		Interval(5 * time.Minute).
+//		Store(inkbunny.NewFileStore("watch.json"), "fox")
+//	for submission, err := range watcher.Watch(ctx) {
+//		if err != nil {
+//			log.Printf("Error polling: %v", err)
+//			continue
+//		}
+//		fmt.Println(submission.Title)
+//	}
+//
+// The first poll of a search only records the high-water mark, unless Backfill is set.
+// Submissions are new when their ID is above the high-water mark, or when they appear among the newest submissions
+// of the last poll without having been returned before. Deleted submissions and changes to the order of the results
+// do not cause submissions to be returned twice.
+//
+// Every search goes through the rate limiters of the Client.
+type Watcher struct {
+	client   *Client
+	user     *User
+	request  SubmissionSearchRequest
+	interval time.Duration
+	store    StateStore
+	key      string
+	pages    int
+	backfill bool
+}
+
+// NewWatcher returns a Watcher for the request, searching as the User.
+func (u *User) NewWatcher(request SubmissionSearchRequest) *Watcher {
+	w := u.Client().NewWatcher(request)
+	w.user = u
+	return w
+}
+
+// NewWatcher returns a Watcher for the request. The request should set a SID, or use User.NewWatcher instead.
+// The Watcher polls every 5 minutes, keeps its state in a MemoryStore, and reads up to 5 pages per poll by default.
+func (c *Client) NewWatcher(request SubmissionSearchRequest) *Watcher {
+	request.OrderBy = types.OrderByCreateDatetime
+	request.Random = types.No
+	request.RID = ""
+	request.GetRID = types.No
+	request.NoSubmissions = types.No
+	request.SubmissionIDsOnly = types.No
+	if request.SubmissionsPerPage == 0 {
+		request.SubmissionsPerPage = 100
+	}
+	return &Watcher{
+		client:   c.Get(),
+		request:  request,
+		interval: 5 * time.Minute,
+		store:    NewMemoryStore(),
+		key:      request.String(),
+		pages:    5,
+	}
+}
+
+// Interval sets the time between polls.
+func (w *Watcher) Interval(interval time.Duration) *Watcher {
+	w.interval = interval
+	return w
+}
+
+// Store sets where the state is saved, under key. The key defaults to the String of the request.
+func (w *Watcher) Store(store StateStore, key string) *Watcher {
+	w.store = store
+	if key != "" {
+		w.key = key
+	}
+	return w
+}
+
+// MaxPages sets how many pages are read per poll when many submissions are new. The default is 5.
+// New submissions beyond the last page are skipped.
+func (w *Watcher) MaxPages(n int) *Watcher {
+	w.pages = max(n, 1)
+	return w
+}
+
+// Backfill returns the submissions of the first page on the first poll, instead of only recording the high-water mark.
+func (w *Watcher) Backfill() *Watcher {
+	w.backfill = true
+	return w
+}
+
+// Poll runs the search once and returns the new submissions, oldest first.
+// The state is saved before returning, so the submissions are not returned again.
+func (w *Watcher) Poll() ([]SubmissionSearch, error) {
+	return w.PollContext(w.client.Context())
+}
+
+// PollContext is like Watcher.Poll but uses ctx for the requests.
+func (w *Watcher) PollContext(ctx context.Context) ([]SubmissionSearch, error) {
+	state, results, found, err := w.poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.save(ctx, state, results, found, len(found)); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// poll runs the search once and returns the loaded state, the results of the search, and the new submissions
+// among them, oldest first. The state is not saved.
+func (w *Watcher) poll(ctx context.Context) (state WatchState, results, found []SubmissionSearch, err error) {
+	state, err = w.store.Load(ctx, w.key)
+	if err != nil {
+		return state, nil, nil, fmt.Errorf("error loading watch state: %w", err)
+	}
+	first := state.Polled.IsZero()
+
+	seen := make(map[types.IntString]bool, len(state.Seen))
+	for _, id := range state.Seen {
+		seen[id] = true
+	}
+	lowest := state.SubmissionID
+	if len(state.Seen) > 0 {
+		lowest = slices.Min(state.Seen)
+	}
+
+	for page := 1; page <= w.pages; page++ {
+		request := w.request
+		request.Page = types.IntString(page)
+		response, err := w.search(ctx, request)
+		if err != nil {
+			return state, nil, nil, err
+		}
+		results = append(results, response.Submissions...)
+		if first || page >= int(response.PagesCount) || len(response.Submissions) == 0 {
+			break
+		}
+		if response.Submissions[len(response.Submissions)-1].SubmissionID <= lowest {
+			break
+		}
+	}
+
+	// Unseen submissions below the high-water mark are only new if a submission of the last poll comes after them.
+	last := -1
+	for i, submission := range results {
+		if seen[submission.SubmissionID] {
+			last = i
+		}
+	}
+	returned := make(map[types.IntString]bool, len(results))
+	for i, submission := range results {
+		if returned[submission.SubmissionID] || seen[submission.SubmissionID] {
+			continue
+		}
+		returned[submission.SubmissionID] = true
+		if first && !w.backfill {
+			continue
+		}
+		if first || submission.SubmissionID > state.SubmissionID || i < last {
+			found = append(found, submission)
+		}
+	}
+	slices.Reverse(found)
+	return state, results, found, nil
+}
+
+// save saves the state of a poll once the first n submissions of found were delivered.
+// The other submissions are left out of the state, so that the next poll returns them again.
+func (w *Watcher) save(ctx context.Context, state WatchState, results, found []SubmissionSearch, n int) error {
+	if n < len(found) {
+		if n == 0 {
+			return nil
+		}
+		undelivered := make(map[types.IntString]bool, len(found)-n)
+		for _, submission := range found[n:] {
+			undelivered[submission.SubmissionID] = true
+		}
+		results = slices.DeleteFunc(slices.Clone(results), func(submission SubmissionSearch) bool {
+			return undelivered[submission.SubmissionID]
+		})
+	}
+
+	next := WatchState{SubmissionID: state.SubmissionID, CreateDate: state.CreateDate, Polled: time.Now()}
+	for _, submission := range results {
+		if submission.SubmissionID > next.SubmissionID {
+			next.SubmissionID = submission.SubmissionID
+			next.CreateDate = submission.CreateDateSystem
+		}
+		if len(next.Seen) < watchWindow && !slices.Contains(next.Seen, submission.SubmissionID) {
+			next.Seen = append(next.Seen, submission.SubmissionID)
+		}
+	}
+	if err := w.store.Save(ctx, w.key, next); err != nil {
+		return fmt.Errorf("error saving watch state: %w", err)
+	}
+	return nil
+}
+
+// Watch returns a sequence of the new submissions, polling until ctx is done or the iteration stops.
+// Errors are yielded without stopping the Watcher, so the iteration must stop to give up after an error.
+// The state is saved as the submissions are delivered, so submissions that were not yielded when ctx is done or
+// the iteration stops are returned again by the next poll.
+func (w *Watcher) Watch(ctx context.Context) iter.Seq2[SubmissionSearch, error] {
+	return func(yield func(SubmissionSearch, error) bool) {
+		for {
+			state, results, found, err := w.poll(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil && !yield(SubmissionSearch{}, err) {
+				return
+			}
+			if err == nil {
+				delivered := 0
+				for _, submission := range found {
+					if !yield(submission, nil) {
+						// The iteration stopped, so an error saving the state cannot be reported.
+						_ = w.save(context.WithoutCancel(ctx), state, results, found, delivered+1)
+						return
+					}
+					delivered++
+				}
+				if err := w.save(ctx, state, results, found, delivered); err != nil && !yield(SubmissionSearch{}, err) {
+					return
+				}
+			}
+			if sleep(ctx, w.interval) != nil {
+				return
+			}
+		}
+	}
+}
+
+// Notify sends the new submissions to ch, polling until ctx is done or a poll fails.
+// It returns the error of the failed poll, or the error of ctx.
+// The state is saved as the submissions are sent, so submissions that were not sent when ctx is done are sent
+// again by the next poll.
+func (w *Watcher) Notify(ctx context.Context, ch chan<- SubmissionSearch) error {
+	for {
+		state, results, found, err := w.poll(ctx)
+		if err != nil {
+			return err
+		}
+		for i, submission := range found {
+			select {
+			case ch <- submission:
+			case <-ctx.Done():
+				if err := w.save(context.WithoutCancel(ctx), state, results, found, i); err != nil {
+					return errors.Join(ctx.Err(), err)
+				}
+				return ctx.Err()
+			}
+		}
+		if err := w.save(ctx, state, results, found, len(found)); err != nil {
+			return err
+		}
+		if err := sleep(ctx, w.interval); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *Watcher) search(ctx context.Context, request SubmissionSearchRequest) (SubmissionSearchResponse, error) {
+	if w.user != nil {
+		return w.user.SearchSubmissionsContext(ctx, request)
+	}
+	return w.client.SearchSubmissionsContext(ctx, request)
+}
@@ -0,0 +1,95 @@
+package inkbunny_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ellypaws/inkbunny"
+	"github.com/ellypaws/inkbunny/inkbunnytest"
+	"github.com/ellypaws/inkbunny/types"
+)
+
+// addNew adds n submissions of "artist" created after the existing ones, and returns their IDs oldest first.
+func addNew(server *inkbunnytest.Server, n int) []types.IntString {
+	ids := make([]types.IntString, n)
+	for i := range n {
+		ids[i] = server.AddSubmission(inkbunny.SubmissionDetails{SubmissionBasic: inkbunny.SubmissionBasic{
+			Username:         "artist",
+			CreateDateSystem: types.Time{Time: time.Now().Add(time.Duration(i) * time.Second)},
+		}})
+	}
+	return ids
+}
+
+func submissionIDs(submissions []inkbunny.SubmissionSearch) []types.IntString {
+	var ids []types.IntString
+	for _, submission := range submissions {
+		ids = append(ids, submission.SubmissionID)
+	}
+	return ids
+}
+
+func TestWatchResumesAfterBreak(t *testing.T) {
+	server, _ := newServer(t, 3)
+	user := login(t, server.Client())
+	watcher := user.NewWatcher(inkbunny.SubmissionSearchRequest{Username: "artist"}).Interval(time.Millisecond)
+	if found, err := watcher.Poll(); err != nil || len(found) != 0 {
+		t.Fatalf("first poll: got %v, %v", found, err)
+	}
+
+	added := addNew(server, 3)
+	var got []types.IntString
+	for submission, err := range watcher.Watch(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, submission.SubmissionID)
+		break
+	}
+	if !slices.Equal(got, added[:1]) {
+		t.Fatalf("got %v, want %v", got, added[:1])
+	}
+
+	found, err := watcher.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(submissionIDs(found), added[1:]) {
+		t.Errorf("after break: got %v, want %v", submissionIDs(found), added[1:])
+	}
+	if found, err := watcher.Poll(); err != nil || len(found) != 0 {
+		t.Errorf("last poll: got %v, %v", submissionIDs(found), err)
+	}
+}
+
+func TestNotifyResumesAfterCancel(t *testing.T) {
+	server, _ := newServer(t, 3)
+	user := login(t, server.Client())
+	watcher := user.NewWatcher(inkbunny.SubmissionSearchRequest{Username: "artist"}).Interval(time.Millisecond)
+	if _, err := watcher.Poll(); err != nil {
+		t.Fatal(err)
+	}
+
+	added := addNew(server, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan inkbunny.SubmissionSearch)
+	done := make(chan error)
+	go func() { done <- watcher.Notify(ctx, ch) }()
+	if got := (<-ch).SubmissionID; got != added[0] {
+		t.Errorf("got %v, want %v", got, added[0])
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+
+	found, err := watcher.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(submissionIDs(found), added[1:]) {
+		t.Errorf("after cancel: got %v, want %v", submissionIDs(found), added[1:])
+	}
+}